@@ -0,0 +1,53 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+)
+
+const bibUsage = `Usage: %[1]s bib convert <in> <out>
+
+Converts a webography between formats. The format of each file follows its
+extension: .bib (BibTeX, input only), .json (CSL-JSON), .ris (RIS), anything
+else is the T:/L:/N:/D: webography format.
+`
+
+// runBib implements the "bib" subcommands that work on webographies alone.
+func runBib(args []string) int {
+	if len(args) != 3 || args[0] != "convert" {
+		fmt.Fprintf(os.Stderr, bibUsage, os.Args[0])
+		return 1
+	}
+	in, out := args[1], args[2]
+
+	bib := ast.NewWebography()
+	if err := bib.LoadFromFile(in); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", in, err)
+		return 1
+	}
+	if err := bib.SaveToFile(out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", out, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/nanomarkdown/nanami/pkg/check"
+)
+
+// runCheck parses documents and validates their references without
+// rendering anything. It fails on any diagnostic, warnings included, so
+// that it can guard CI.
+func runCheck(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	webographyPath := flags.String("webography", "",
+		"webography `file` to check against (default: webography next to each input)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s check [-webography path] <input.nama>...\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return 1
+	}
+
+	status := 0
+	for _, inputPath := range flags.Args() {
+		doc, diags, err := parseDocument(inputPath, *webographyPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+
+		diags = append(diags, check.Document(doc).WithFile(inputPath)...)
+		printDiagnostics(diags)
+		if len(diags) > 0 {
+			status = 1
+		}
+	}
+	return status
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nanomarkdown/nanami/pkg/links"
+)
+
+// runLinks checks the inline links of documents and the URLs of their
+// webographies. Results are cached on disk so that repeated runs only go
+// to the network for stale entries. It fails on dead links; redirects and
+// missing archive copies are only reported.
+func runLinks(args []string) int {
+	flags := flag.NewFlagSet("links", flag.ExitOnError)
+	webographyPath := flags.String("webography", "",
+		"webography `file` to check (default: webography next to each input)")
+	cachePath := flags.String("cache", "", "cache `file` (default: links.json in the user cache directory)")
+	maxAge := flags.Duration("max-age", 24*time.Hour, "how long cached results stay valid; 0 rechecks everything")
+	timeout := flags.Duration("timeout", 15*time.Second, "timeout for each request")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s links [-webography path] [-cache file] [-max-age d] <input.nama>...\n",
+			os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return 1
+	}
+
+	if *cachePath == "" {
+		path, err := links.DefaultCachePath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error locating the link cache: %v\n", err)
+			return 1
+		}
+		*cachePath = path
+	}
+	cache, err := links.OpenCache(*cachePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading the link cache: %v\n", err)
+		return 1
+	}
+
+	checker := links.NewChecker(nil, *timeout)
+	checker.Cache = cache
+	checker.MaxAge = *maxAge
+
+	status := 0
+	for _, inputPath := range flags.Args() {
+		doc, diags, err := parseDocument(inputPath, *webographyPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+
+		diags = append(diags, links.Document(context.Background(), doc, checker).WithFile(inputPath)...)
+		printDiagnostics(diags)
+		if diags.HasErrors() {
+			status = 1
+		}
+	}
+
+	if err := cache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error saving the link cache: %v\n", err)
+		return 1
+	}
+	return status
+}
@@ -19,41 +19,128 @@ along with nanami. If not, see <https://www.gnu.org/licenses/>.
 package main
 
 import (
-	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/nanomarkdown/nanami/pkg/ast"
+	"github.com/nanomarkdown/nanami/pkg/cite"
+	"github.com/nanomarkdown/nanami/pkg/diag"
 	"github.com/nanomarkdown/nanami/pkg/parser"
+	"github.com/nanomarkdown/nanami/pkg/renderer"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <input.nama>\n", os.Args[0])
-		os.Exit(1)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "bib":
+			os.Exit(runBib(os.Args[2:]))
+		case "check":
+			os.Exit(runCheck(os.Args[2:]))
+		case "links":
+			os.Exit(runLinks(os.Args[2:]))
+		}
 	}
-	inputPath := os.Args[1]
-	file, err := os.Open(inputPath)
+	os.Exit(runRender(os.Args[1:]))
+}
+
+// runRender renders a document to HTML on stdout.
+func runRender(args []string) int {
+	flags := flag.NewFlagSet("nanami", flag.ExitOnError)
+	webographyPath := flags.String("webography", "",
+		"webography `file` to resolve references against (default: webography next to the input)")
+	styleName := flags.String("style", "",
+		"citation `style`: a built-in name ("+strings.Join(cite.BuiltinNames(), ", ")+
+			") or a style file; overrides the document's style: header")
+	unsafe := flags.Bool("unsafe", false,
+		"write raw HTML, text, titles and URLs without escaping them; only for trusted documents")
+	rawHTML := flags.Bool("raw-html", false,
+		"write html blocks and {html:...} as they are while escaping everything else; only for trusted documents")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-webography path] [-style name] [-raw-html] [-unsafe] <input.nama>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s check [-webography path] <input.nama>...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s links [-webography path] [-cache file] <input.nama>...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s bib convert <in> <out>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return 1
+	}
+	inputPath := flags.Arg(0)
+
+	doc, diags, err := parseDocument(inputPath, *webographyPath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening input file: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	printDiagnostics(diags)
+	if diags.HasErrors() {
+		return 1
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	html := renderer.NewHTML()
+	html.Unsafe = *unsafe
+	html.AllowRawHTML = *rawHTML
+	if html.Style, err = loadStyle(*styleName, inputPath, doc.Style); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading citation style: %v\n", err)
+		return 1
 	}
-	if err := scanner.Err(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading input file: %v\n", err)
-		os.Exit(1)
+	printDiagnostics(html.Check(doc).WithFile(inputPath))
+
+	if err := html.Render(os.Stdout, doc); err != nil {
+		fmt.Fprintf(os.Stderr, "Render error: %v\n", err)
+		return 1
 	}
+	return 0
+}
 
-	doc, err := parser.ParseFile(lines)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Parse error: %v\n", err)
-		os.Exit(1)
+// parseDocument parses the document at inputPath against the webography at
+// webographyPath, or the default one when that is empty. Problems in the
+// document come back as diagnostics naming inputPath; err is only set when
+// nothing could be parsed.
+func parseDocument(inputPath, webographyPath string) (*ast.Document, diag.List, error) {
+	var opts parser.Options
+	if webographyPath != "" {
+		bib, err := parser.LoadWebography(webographyPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error loading webography: %w", err)
+		}
+		opts.Webography = bib
+	}
+
+	doc, err := parser.New(opts).ParsePath(inputPath)
+	var diags diag.List
+	if errors.As(err, &diags) {
+		return doc, diags.WithFile(inputPath), nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("Error reading input file: %w", err)
+	}
+	return doc, nil, nil
+}
+
+func printDiagnostics(diags diag.List) {
+	for _, d := range diags {
+		fmt.Fprintln(os.Stderr, d)
 	}
+}
 
-	doc.Render(os.Stdout, 0)
+// loadStyle resolves the citation style for the document at docPath. The
+// command line wins over the document's style: header; style files named in
+// the header are looked up relative to the document.
+func loadStyle(flagStyle, docPath, docStyle string) (*cite.Style, error) {
+	switch {
+	case flagStyle != "":
+		return cite.Load(flagStyle)
+	case docStyle == "":
+		return cite.Builtin(cite.Default), nil
+	case cite.IsBuiltin(docStyle) || filepath.IsAbs(docStyle):
+		return cite.Load(docStyle)
+	}
+	return cite.Load(filepath.Join(filepath.Dir(docPath), docStyle))
 }
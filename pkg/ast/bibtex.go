@@ -0,0 +1,363 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+var bibtexMonths = map[string]string{
+	"jan": "January", "feb": "February", "mar": "March", "apr": "April",
+	"may": "May", "jun": "June", "jul": "July", "aug": "August",
+	"sep": "September", "oct": "October", "nov": "November", "dec": "December",
+}
+
+// LoadBibTeX adds the entries of a BibTeX database. Each entry's citation key
+// becomes its keyword; title, url, author, date (or year and month),
+// urldate, publisher (or organization), archiveurl, language (or langid)
+// and note are kept, other fields are ignored.
+func (b *Webography) LoadBibTeX(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s := &bibtexScanner{src: string(data), macros: map[string]string{}}
+	for name, month := range bibtexMonths {
+		s.macros[name] = month
+	}
+
+	for s.skipTo('@') {
+		kind := strings.ToLower(s.ident())
+		s.skipSpace()
+		closer, ok := s.open()
+		if !ok {
+			return s.errorf("expected { or ( after @%s", kind)
+		}
+
+		switch kind {
+		case "comment", "preamble":
+			if !s.skipBalanced(closer) {
+				return s.errorf("unterminated @%s", kind)
+			}
+		case "string":
+			fields, err := s.fields(closer)
+			if err != nil {
+				return err
+			}
+			for name, value := range fields {
+				s.macros[name] = value
+			}
+		default:
+			s.skipSpace()
+			line := s.line()
+			key := strings.TrimSpace(s.until(",\n" + string(closer)))
+			if key == "" {
+				return s.errorf("@%s entry without a citation key", kind)
+			}
+			fields, err := s.fields(closer)
+			if err != nil {
+				return err
+			}
+			b.load(bibtexEntry(key, fields), line)
+		}
+	}
+
+	return nil
+}
+
+func bibtexEntry(key string, fields map[string]string) *WBibEntry {
+	field := func(name string) string {
+		return cleanBibTeX(fields[name])
+	}
+
+	entry := &WBibEntry{
+		Keyword:    key,
+		URL:        field("url"),
+		Name:       field("title"),
+		Date:       field("date"),
+		Publisher:  field("publisher"),
+		AccessDate: field("urldate"),
+		ArchiveURL: field("archiveurl"),
+		Language:   field("language"),
+		Note:       field("note"),
+	}
+
+	if entry.Publisher == "" {
+		entry.Publisher = field("organization")
+	}
+	if entry.Language == "" {
+		entry.Language = field("langid")
+	}
+	if entry.URL == "" {
+		entry.URL = bibtexURLCommand(fields["howpublished"])
+	}
+	if entry.Date == "" {
+		entry.Date = strings.TrimSpace(field("month") + " " + field("year"))
+	}
+	if authors := fields["author"]; authors != "" {
+		entry.Authors = splitBibTeXAuthors(authors)
+	}
+
+	return entry
+}
+
+// bibtexURLCommand extracts the argument of a \url{...} command, which older
+// styles put in howpublished for lack of a url field.
+func bibtexURLCommand(value string) string {
+	const prefix = `\url{`
+	i := strings.Index(value, prefix)
+	if i == -1 {
+		return ""
+	}
+	rest := value[i+len(prefix):]
+	if end := strings.IndexByte(rest, '}'); end != -1 {
+		return rest[:end]
+	}
+	return ""
+}
+
+// splitBibTeXAuthors splits an author field on the " and " separators that
+// are not inside braces.
+func splitBibTeXAuthors(value string) []string {
+	var authors []string
+	depth, start := 0, 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		}
+		if depth == 0 && strings.HasPrefix(value[i:], " and ") {
+			authors = append(authors, value[start:i])
+			start = i + len(" and ")
+			i = start - 1
+		}
+	}
+	authors = append(authors, value[start:])
+
+	for i, author := range authors {
+		authors[i] = cleanBibTeX(author)
+	}
+	return authors
+}
+
+// cleanBibTeX drops grouping braces, unescapes the common special
+// characters and collapses whitespace.
+func cleanBibTeX(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '{' || c == '}':
+		case c == '\\' && i+1 < len(value) && strings.IndexByte(`&%$#_{}`, value[i+1]) != -1:
+			b.WriteByte(value[i+1])
+			i++
+		case c == '~':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// bibtexScanner walks a BibTeX database held in memory.
+type bibtexScanner struct {
+	src    string
+	pos    int
+	macros map[string]string
+}
+
+func (s *bibtexScanner) line() int {
+	return strings.Count(s.src[:s.pos], "\n") + 1
+}
+
+func (s *bibtexScanner) errorf(format string, args ...any) error {
+	return fmt.Errorf("bibtex: line %d: %s", s.line(), fmt.Sprintf(format, args...))
+}
+
+// skipTo moves past the next occurrence of c and reports whether there was
+// one. Text between entries is a comment in BibTeX.
+func (s *bibtexScanner) skipTo(c byte) bool {
+	i := strings.IndexByte(s.src[s.pos:], c)
+	if i == -1 {
+		s.pos = len(s.src)
+		return false
+	}
+	s.pos += i + 1
+	return true
+}
+
+func (s *bibtexScanner) skipSpace() {
+	for s.pos < len(s.src) && unicode.IsSpace(rune(s.src[s.pos])) {
+		s.pos++
+	}
+}
+
+func (s *bibtexScanner) ident() string {
+	start := s.pos
+	for s.pos < len(s.src) {
+		c := rune(s.src[s.pos])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && !strings.ContainsRune("_-:.+/", c) {
+			break
+		}
+		s.pos++
+	}
+	return s.src[start:s.pos]
+}
+
+// until returns the text up to, but not including, the first byte found in
+// stops.
+func (s *bibtexScanner) until(stops string) string {
+	start := s.pos
+	for s.pos < len(s.src) && strings.IndexByte(stops, s.src[s.pos]) == -1 {
+		s.pos++
+	}
+	return s.src[start:s.pos]
+}
+
+// open consumes the delimiter opening an entry and returns the one that
+// closes it.
+func (s *bibtexScanner) open() (byte, bool) {
+	if s.pos >= len(s.src) {
+		return 0, false
+	}
+	switch s.src[s.pos] {
+	case '{':
+		s.pos++
+		return '}', true
+	case '(':
+		s.pos++
+		return ')', true
+	}
+	return 0, false
+}
+
+func (s *bibtexScanner) skipBalanced(closer byte) bool {
+	depth := 0
+	for ; s.pos < len(s.src); s.pos++ {
+		switch c := s.src[s.pos]; {
+		case c == '{':
+			depth++
+		case c == '}' && depth > 0:
+			depth--
+		case c == closer && depth == 0:
+			s.pos++
+			return true
+		}
+	}
+	return false
+}
+
+// fields reads comma separated name = value pairs up to closer. Field names
+// are lower-cased; values are returned raw, see cleanBibTeX.
+func (s *bibtexScanner) fields(closer byte) (map[string]string, error) {
+	fields := map[string]string{}
+	for {
+		s.skipSpace()
+		if s.pos >= len(s.src) {
+			return nil, s.errorf("unterminated entry")
+		}
+		switch s.src[s.pos] {
+		case closer:
+			s.pos++
+			return fields, nil
+		case ',':
+			s.pos++
+			continue
+		}
+
+		name := strings.ToLower(s.ident())
+		if name == "" {
+			return nil, s.errorf("expected a field name, got %q", s.src[s.pos])
+		}
+		s.skipSpace()
+		if s.pos >= len(s.src) || s.src[s.pos] != '=' {
+			return nil, s.errorf("expected = after %s", name)
+		}
+		s.pos++
+
+		value, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+}
+
+// value reads a field value: braced or quoted strings, numbers and macro
+// names, concatenated with #.
+func (s *bibtexScanner) value() (string, error) {
+	var b strings.Builder
+	for {
+		s.skipSpace()
+		if s.pos >= len(s.src) {
+			return "", s.errorf("unterminated field value")
+		}
+
+		switch c := s.src[s.pos]; {
+		case c == '{':
+			s.pos++
+			start := s.pos
+			if !s.skipBalanced('}') {
+				return "", s.errorf("unterminated braced value")
+			}
+			b.WriteString(s.src[start : s.pos-1])
+		case c == '"':
+			s.pos++
+			start := s.pos
+			depth := 0
+			for ; s.pos < len(s.src) && (s.src[s.pos] != '"' || depth > 0); s.pos++ {
+				switch s.src[s.pos] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				}
+			}
+			if s.pos >= len(s.src) {
+				return "", s.errorf("unterminated quoted value")
+			}
+			b.WriteString(s.src[start:s.pos])
+			s.pos++
+		default:
+			word := s.ident()
+			if word == "" {
+				return "", s.errorf("unexpected %q in field value", c)
+			}
+			if macro, ok := s.macros[strings.ToLower(word)]; ok {
+				b.WriteString(macro)
+			} else {
+				b.WriteString(word)
+			}
+		}
+
+		s.skipSpace()
+		if s.pos < len(s.src) && s.src[s.pos] == '#' {
+			s.pos++
+			continue
+		}
+		return b.String(), nil
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadBibTeX(t *testing.T) {
+	bib := NewWebography()
+	err := bib.LoadBibTeX(strings.NewReader(`
+This text between entries is ignored.
+@string{ site = "Example {Site}" }
+@comment{ @misc{ignored, title = {Ignored}} }
+
+@online{smthiread,
+  title   = {Something {I} Read \& Liked},
+  author  = {Doe, Jane and {Barnes and Noble}},
+  url     = {https://example.com/whatever.xhtml},
+  date    = {2019-04-01},
+  urldate = {2024-01-02},
+}
+
+@misc(anthrthngiread,
+  title        = site # ": " # "Another Thing",
+  howpublished = "\url{https://example.com/sowhat.xhtml}",
+  year         = 2020,
+  month        = jan
+)
+`))
+	if err != nil {
+		t.Fatalf("LoadBibTeX returned %v", err)
+	}
+
+	want := map[string]WBibEntry{
+		"smthiread": {
+			Keyword:    "smthiread",
+			URL:        "https://example.com/whatever.xhtml",
+			Name:       "Something I Read & Liked",
+			Date:       "2019-04-01",
+			Authors:    []string{"Doe, Jane", "Barnes and Noble"},
+			AccessDate: "2024-01-02",
+		},
+		"anthrthngiread": {
+			Keyword: "anthrthngiread",
+			URL:     "https://example.com/sowhat.xhtml",
+			Name:    "Example Site: Another Thing",
+			Date:    "January 2020",
+		},
+	}
+	for key, entry := range want {
+		got, ok := bib.Lookup(key)
+		if !ok {
+			t.Errorf("Expected an entry for %q", key)
+			continue
+		}
+		if !reflect.DeepEqual(*got, entry) {
+			t.Errorf("Entry %q: expected %+v, got %+v", key, entry, *got)
+		}
+	}
+	if _, ok := bib.Lookup("ignored"); ok {
+		t.Errorf("Expected entries inside @comment to be skipped")
+	}
+}
+
+func TestLoadBibTeXErrors(t *testing.T) {
+	for _, input := range []string{
+		"@misc{a, title = {unterminated}",
+		"@misc{a, title {x}}",
+		"@misc{, title = {x}}",
+	} {
+		if err := NewWebography().LoadBibTeX(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+// CalloutType says what kind of remark a callout block holds.
+type CalloutType int
+
+const (
+	// CalloutNote is written note { ... }.
+	CalloutNote CalloutType = iota
+	// CalloutWarning is written warning { ... }.
+	CalloutWarning
+	// CalloutAside is written aside { ... }.
+	CalloutAside
+)
+
+// String returns the block name of the callout type.
+func (t CalloutType) String() string {
+	switch t {
+	case CalloutNote:
+		return "note"
+	case CalloutWarning:
+		return "warning"
+	case CalloutAside:
+		return "aside"
+	}
+	return "unknown"
+}
+
+// CalloutNode is a remark set apart from the running text, with an
+// optional title: note(Before you start) { ... }.
+type CalloutNode struct {
+	Span
+	Type  CalloutType
+	Title []Inline
+	// Content holds the text of the callout laid out like that of a
+	// TextNode; Paragraphs holds it parsed.
+	Content    string
+	Paragraphs []Paragraph
+}
+
+func (c *CalloutNode) Kind() NodeKind {
+	return KindCallout
+}
+
+func (c *CalloutNode) Children() []Node {
+	return nil
+}
@@ -19,8 +19,32 @@ along with nanami. If not, see <https://www.gnu.org/licenses/>.
 package ast
 
 type CaseNode struct {
-	Title    string
-	Link     string
-	Body     []Node
-	SubCases []CaseNode
+	Span
+	Title string
+	Link  string
+	// Body holds the blocks and sub-cases of the case in document order.
+	Body []Node
+}
+
+func (c *CaseNode) Kind() NodeKind {
+	return KindCase
+}
+
+func (c *CaseNode) Children() []Node {
+	return c.Body
+}
+
+// SubCases returns the cases nested directly in c, in document order.
+func (c *CaseNode) SubCases() []*CaseNode {
+	return casesOf(c.Body)
+}
+
+func casesOf(nodes []Node) []*CaseNode {
+	var cases []*CaseNode
+	for _, n := range nodes {
+		if c, ok := n.(*CaseNode); ok {
+			cases = append(cases, c)
+		}
+	}
+	return cases
 }
@@ -0,0 +1,88 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+import "strings"
+
+// CitationMode says how a citation names the works it cites.
+type CitationMode int
+
+const (
+	// CiteNormal is a plain parenthetical or numbered citation: ${key}.
+	CiteNormal CitationMode = iota
+	// CiteSuppressAuthor leaves the author out, for prose that already
+	// names them: ${-key}.
+	CiteSuppressAuthor
+	// CiteNarrative puts the author's name in the running text followed by
+	// the rest of the citation: ${+key}.
+	CiteNarrative
+)
+
+// CitationItem is one cited entry, with an optional locator such as
+// "p. 12" or "sec. 3".
+type CitationItem struct {
+	Keyword string
+	Locator string
+}
+
+// Citation is a ${...} reference to webography entries.
+type Citation struct {
+	Mode  CitationMode
+	Items []CitationItem
+}
+
+func (c *Citation) Inlines() []Inline {
+	return nil
+}
+
+// ParseCitation parses the body of a ${...} reference. Items are separated
+// by semicolons and each may carry a locator after a comma:
+//
+//	key
+//	key, p. 12
+//	a; b, ch. 2; c
+//
+// A leading - suppresses the author and a leading + makes the citation
+// narrative; either applies to every item. Empty items are dropped.
+func ParseCitation(body string) Citation {
+	var citation Citation
+
+	body = strings.TrimSpace(body)
+	switch {
+	case strings.HasPrefix(body, "-"):
+		citation.Mode = CiteSuppressAuthor
+		body = body[1:]
+	case strings.HasPrefix(body, "+"):
+		citation.Mode = CiteNarrative
+		body = body[1:]
+	}
+
+	for _, part := range strings.Split(body, ";") {
+		keyword, locator, _ := strings.Cut(part, ",")
+		item := CitationItem{
+			Keyword: strings.TrimSpace(keyword),
+			Locator: strings.TrimSpace(locator),
+		}
+		if item.Keyword != "" {
+			citation.Items = append(citation.Items, item)
+		}
+	}
+
+	return citation
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+// CodeNode is a block of source code, written code(lang) { ... }. The code
+// must be indented deeper than code, as the block ends at the first line
+// holding only } that is not. Content is kept as written apart from the
+// indentation shared by all its lines.
+type CodeNode struct {
+	Span
+	// Lang names the language of the code, as given in code(lang). It may
+	// be empty.
+	Lang    string
+	Content string
+}
+
+func (c *CodeNode) Kind() NodeKind {
+	return KindCode
+}
+
+func (c *CodeNode) Children() []Node {
+	return nil
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// cslItem is the subset of a CSL-JSON item that maps onto WBibEntry.
+type cslItem struct {
+	ID        cslID     `json:"id"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title,omitempty"`
+	URL       string    `json:"URL,omitempty"`
+	Author    []cslName `json:"author,omitempty"`
+	Issued    *cslDate  `json:"issued,omitempty"`
+	Accessed  *cslDate  `json:"accessed,omitempty"`
+	Publisher string    `json:"publisher,omitempty"`
+	Container string    `json:"container-title,omitempty"`
+	Language  string    `json:"language,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	Custom    cslCustom `json:"custom,omitzero"`
+}
+
+// cslCustom holds the fields CSL has no variable for, in the custom object
+// of CSL-JSON 1.0.2.
+type cslCustom struct {
+	ArchiveURL string `json:"archive-url,omitempty"`
+}
+
+type cslName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+type cslDate struct {
+	DateParts [][]any `json:"date-parts,omitempty"`
+	Raw       string  `json:"raw,omitempty"`
+	Literal   string  `json:"literal,omitempty"`
+}
+
+// cslID accepts both the string and the numeric ids found in the wild.
+type cslID string
+
+func (id *cslID) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*id = cslID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("csl-json: id must be a string or a number, got %s", data)
+	}
+	*id = cslID(n.String())
+	return nil
+}
+
+// LoadCSLJSON adds the items of a CSL-JSON array, as exported by Zotero and
+// most other reference managers. Item ids become keywords.
+func (b *Webography) LoadCSLJSON(r io.Reader) error {
+	var items []cslItem
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return fmt.Errorf("csl-json: %w", err)
+	}
+
+	for i, item := range items {
+		if item.ID == "" {
+			return fmt.Errorf("csl-json: item %d has no id", i+1)
+		}
+		entry := &WBibEntry{
+			Keyword:    string(item.ID),
+			URL:        item.URL,
+			Name:       item.Title,
+			Date:       item.Issued.String(),
+			Publisher:  item.Publisher,
+			AccessDate: item.Accessed.String(),
+			ArchiveURL: item.Custom.ArchiveURL,
+			Language:   item.Language,
+			Note:       item.Note,
+		}
+		if entry.Publisher == "" {
+			// Web pages name their site as the container.
+			entry.Publisher = item.Container
+		}
+		for _, name := range item.Author {
+			entry.Authors = append(entry.Authors, name.String())
+		}
+		b.load(entry, 0)
+	}
+
+	return nil
+}
+
+// WriteCSLJSON writes the webography as a CSL-JSON array of webpage items.
+func (b *Webography) WriteCSLJSON(w io.Writer) error {
+	items := []cslItem{}
+	for _, entry := range b.Entries() {
+		item := cslItem{
+			ID:        cslID(entry.Keyword),
+			Type:      "webpage",
+			Title:     entry.Name,
+			URL:       entry.URL,
+			Issued:    newCSLDate(entry.Date),
+			Accessed:  newCSLDate(entry.AccessDate),
+			Container: entry.Publisher,
+			Language:  entry.Language,
+			Note:      entry.Note,
+			Custom:    cslCustom{ArchiveURL: entry.ArchiveURL},
+		}
+		for _, author := range entry.Authors {
+			item.Author = append(item.Author, newCSLName(author))
+		}
+		items = append(items, item)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(items)
+}
+
+// String formats a name the way BibTeX authors are kept: "Family, Given".
+func (n cslName) String() string {
+	switch {
+	case n.Literal != "":
+		return n.Literal
+	case n.Given != "":
+		return n.Family + ", " + n.Given
+	}
+	return n.Family
+}
+
+// newCSLName splits an author kept as "Family, Given"; anything else is kept
+// as a literal name.
+func newCSLName(author string) cslName {
+	if family, given, found := strings.Cut(author, ", "); found {
+		return cslName{Family: family, Given: given}
+	}
+	return cslName{Literal: author}
+}
+
+// String formats the date as YYYY[-MM[-DD]] when it has date parts.
+func (d *cslDate) String() string {
+	if d == nil {
+		return ""
+	}
+	if len(d.DateParts) > 0 {
+		var parts []int
+		for _, part := range d.DateParts[0] {
+			var n int
+			switch part := part.(type) {
+			case float64:
+				n = int(part)
+			case string:
+				n, _ = strconv.Atoi(part)
+			}
+			parts = append(parts, n)
+		}
+		return formatDateParts(parts)
+	}
+	if d.Raw != "" {
+		return d.Raw
+	}
+	return d.Literal
+}
+
+func newCSLDate(date string) *cslDate {
+	if date == "" {
+		return nil
+	}
+	parts := parseDateParts(date)
+	if parts == nil {
+		return &cslDate{Raw: date}
+	}
+	dateParts := make([]any, len(parts))
+	for i, part := range parts {
+		dateParts[i] = part
+	}
+	return &cslDate{DateParts: [][]any{dateParts}}
+}
+
+// parseDateParts splits an ISO 8601 style YYYY, YYYY-MM or YYYY-MM-DD date.
+// It also accepts the slash separators used by RIS. Other dates give nil.
+func parseDateParts(date string) []int {
+	fields := strings.FieldsFunc(date, func(c rune) bool { return c == '-' || c == '/' })
+	if len(fields) == 0 || len(fields) > 3 || len(fields[0]) != 4 {
+		return nil
+	}
+	parts := make([]int, len(fields))
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return nil
+		}
+		parts[i] = n
+	}
+	return parts
+}
+
+func formatDateParts(parts []int) string {
+	var b strings.Builder
+	for i, part := range parts {
+		if i == 0 {
+			fmt.Fprintf(&b, "%04d", part)
+		} else {
+			fmt.Fprintf(&b, "-%02d", part)
+		}
+	}
+	return b.String()
+}
@@ -19,8 +19,28 @@ along with nanami. If not, see <https://www.gnu.org/licenses/>.
 package ast
 
 type Document struct {
-	Title   string
+	Span
+	Title string
+	// Style names the citation style picked with a style: header.
+	Style string
+	// Content holds the top-level blocks and cases in document order.
 	Content []Node
-	Cases   []CaseNode
-	NoNLP   bool
+	// NoNLP is set by the !nlp header: text blocks are written without
+	// paragraph markup unless they ask for it with text(nlp).
+	NoNLP bool
+	// Webography resolves ${keyword} references found in the content.
+	Webography *Webography
+}
+
+func (d *Document) Kind() NodeKind {
+	return KindDocument
+}
+
+func (d *Document) Children() []Node {
+	return d.Content
+}
+
+// Cases returns the top-level cases of the document, in document order.
+func (d *Document) Cases() []*CaseNode {
+	return casesOf(d.Content)
 }
@@ -0,0 +1,61 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+// FigureNode is a numbered figure, written figure(id) { ... }. Its body
+// holds a caption: line, lines of inline content such as {img/...}{alt}
+// images and blocks such as tables or code. {ref:id} refers to it.
+type FigureNode struct {
+	Span
+	// ID is the name given in figure(id); it may be empty.
+	ID string
+	// Number counts the figures of the document from 1, in document order.
+	Number  int
+	Caption []Inline
+	// Content holds the lines of inline content, Body the blocks.
+	Content []Inline
+	Body    []Node
+}
+
+func (f *FigureNode) Kind() NodeKind {
+	return KindFigure
+}
+
+func (f *FigureNode) Children() []Node {
+	return f.Body
+}
+
+// Figures returns the figures found in n and its descendants by ID.
+// Figures without an ID are left out.
+func Figures(n Node) map[string]*FigureNode {
+	figures := map[string]*FigureNode{}
+	var walk func(n Node)
+	walk = func(n Node) {
+		if f, ok := n.(*FigureNode); ok && f.ID != "" {
+			if _, seen := figures[f.ID]; !seen {
+				figures[f.ID] = f
+			}
+		}
+		for _, child := range n.Children() {
+			walk(child)
+		}
+	}
+	walk(n)
+	return figures
+}
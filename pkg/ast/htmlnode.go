@@ -0,0 +1,35 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+// HTMLNode is a block of raw HTML, written html { ... }. The HTML renderer
+// only writes its content as it is when raw HTML is allowed, which is meant
+// for trusted documents; otherwise the content is escaped.
+type HTMLNode struct {
+	Span
+	Content string
+}
+
+func (h *HTMLNode) Kind() NodeKind {
+	return KindHTML
+}
+
+func (h *HTMLNode) Children() []Node {
+	return nil
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+// Inline is an element of the inline content of a text or sources block.
+type Inline interface {
+	// Inlines returns the inline elements nested in this one.
+	Inlines() []Inline
+}
+
+// Paragraph is one paragraph of a text block.
+type Paragraph []Inline
+
+// Text is a run of plain text.
+type Text struct {
+	Value string
+}
+
+func (t *Text) Inlines() []Inline {
+	return nil
+}
+
+// LineBreak is a hard line break, written as a backslash at the end of a
+// line.
+type LineBreak struct{}
+
+func (l *LineBreak) Inlines() []Inline {
+	return nil
+}
+
+// InlineStyle is the kind of markup a Styled element applies.
+type InlineStyle int
+
+const (
+	Emphasis    InlineStyle = iota // {*text}
+	Strong                         // {**text}
+	Strike                         // {~text}
+	Subscript                      // {_text}
+	Superscript                    // {^text}
+	Highlight                      // {=text}
+)
+
+func (s InlineStyle) String() string {
+	switch s {
+	case Emphasis:
+		return "emphasis"
+	case Strong:
+		return "strong"
+	case Strike:
+		return "strike"
+	case Subscript:
+		return "subscript"
+	case Superscript:
+		return "superscript"
+	case Highlight:
+		return "highlight"
+	}
+	return "unknown"
+}
+
+// Styled applies a style to the inline content it holds.
+type Styled struct {
+	Style    InlineStyle
+	Children []Inline
+}
+
+func (s *Styled) Inlines() []Inline {
+	return s.Children
+}
+
+// Code is inline code, written {`code}. Its value is kept verbatim.
+type Code struct {
+	Value string
+}
+
+func (c *Code) Inlines() []Inline {
+	return nil
+}
+
+// RawHTML is raw inline HTML, written {html:...}. Like HTMLNode it is
+// only written out as it is when raw HTML is allowed.
+type RawHTML struct {
+	Value string
+}
+
+func (r *RawHTML) Inlines() []Inline {
+	return nil
+}
+
+// Link is a link to URL, written {https://...} or {https://...}{text}. With
+// no children the URL itself is shown.
+type Link struct {
+	URL      string
+	Children []Inline
+}
+
+func (l *Link) Inlines() []Inline {
+	return l.Children
+}
+
+// Image is an image, written {img/path}{alt}.
+type Image struct {
+	Src string
+	Alt string
+}
+
+func (i *Image) Inlines() []Inline {
+	return nil
+}
+
+// FootnotesScope selects the webography entries a Footnotes element lists.
+type FootnotesScope int
+
+const (
+	// FootnotesCurrent lists the entries of the current numbering: {footnotes}.
+	FootnotesCurrent FootnotesScope = iota
+	// FootnotesCase lists the entries cited in the enclosing top-level
+	// case, which then numbers its citations on its own: {footnotes:case}.
+	FootnotesCase
+	// FootnotesAll lists every entry cited in the document: {footnotes:all}.
+	FootnotesAll
+)
+
+// Footnotes is the place where cited webography entries are listed.
+type Footnotes struct {
+	Scope FootnotesScope
+}
+
+func (f *Footnotes) Inlines() []Inline {
+	return nil
+}
+
+// Ref is a {ref:id} reference to the figure with that ID.
+type Ref struct {
+	ID string
+}
+
+func (r *Ref) Inlines() []Inline {
+	return nil
+}
+
+// InlineContent returns the inline content held directly by n: the
+// paragraphs of a text block one after another, those of a quote
+// followed by its attribution or those of a callout after its title, the
+// content of a sources block, the items of a list, leaving out its
+// sublists, the caption and cells of a table, or the lines of a figure
+// followed by its caption.
+func InlineContent(n Node) []Inline {
+	switch n := n.(type) {
+	case *TableNode:
+		content := append([]Inline(nil), n.Caption...)
+		for _, row := range append([][]TableCell{n.Header}, n.Rows...) {
+			for _, cell := range row {
+				content = append(content, cell.Content...)
+			}
+		}
+		return content
+	case *FigureNode:
+		return append(append([]Inline(nil), n.Content...), n.Caption...)
+	case *ListNode:
+		var content []Inline
+		for _, item := range n.Items {
+			content = append(content, item.Content...)
+		}
+		return content
+	case *TextNode:
+		return joinParagraphs(n.Paragraphs)
+	case *QuoteNode:
+		return append(joinParagraphs(n.Paragraphs), n.Attribution...)
+	case *CalloutNode:
+		return append(append([]Inline(nil), n.Title...), joinParagraphs(n.Paragraphs)...)
+	case *SourcesNode:
+		return n.Inline
+	}
+	return nil
+}
+
+func joinParagraphs(paragraphs []Paragraph) []Inline {
+	var content []Inline
+	for _, paragraph := range paragraphs {
+		content = append(content, paragraph...)
+	}
+	return content
+}
+
+// WalkInline calls visit for each of nodes and everything nested in them,
+// in document order.
+func WalkInline(nodes []Inline, visit func(Inline)) {
+	for _, n := range nodes {
+		visit(n)
+		WalkInline(n.Inlines(), visit)
+	}
+}
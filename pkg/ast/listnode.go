@@ -0,0 +1,53 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+// ListNode is a bulleted list, written list { ... }, or a numbered one,
+// written olist { ... }. Each line of the body is an item; a line holding
+// list { or olist { opens a sublist of the item before it, which ends at a
+// line holding only }.
+type ListNode struct {
+	Span
+	Ordered bool
+	Items   []*ListItem
+}
+
+// ListItem is one item of a list.
+type ListItem struct {
+	// Text is the item as written; Content holds it parsed.
+	Text    string
+	Content []Inline
+	// Sublists are the lists nested in the item.
+	Sublists []*ListNode
+}
+
+func (l *ListNode) Kind() NodeKind {
+	return KindList
+}
+
+// Children returns the sublists of the list's items.
+func (l *ListNode) Children() []Node {
+	var children []Node
+	for _, item := range l.Items {
+		for _, sublist := range item.Sublists {
+			children = append(children, sublist)
+		}
+	}
+	return children
+}
@@ -18,4 +18,82 @@ along with nanami. If not, see <https://www.gnu.org/licenses/>.
 
 package ast
 
-type Node any
+// NodeKind identifies the concrete type of a Node without a type switch.
+type NodeKind int
+
+const (
+	KindDocument NodeKind = iota
+	KindCase
+	KindText
+	KindSources
+	KindHTML
+	KindCode
+	KindList
+	KindTable
+	KindQuote
+	KindCallout
+	KindFigure
+)
+
+func (k NodeKind) String() string {
+	switch k {
+	case KindDocument:
+		return "document"
+	case KindCase:
+		return "case"
+	case KindText:
+		return "text"
+	case KindSources:
+		return "sources"
+	case KindHTML:
+		return "html"
+	case KindCode:
+		return "code"
+	case KindList:
+		return "list"
+	case KindTable:
+		return "table"
+	case KindQuote:
+		return "quote"
+	case KindCallout:
+		return "callout"
+	case KindFigure:
+		return "figure"
+	}
+	return "unknown"
+}
+
+// Node is implemented by every element of a parsed document.
+type Node interface {
+	Kind() NodeKind
+	Children() []Node
+	// Start returns the position of the node's first character.
+	Start() Position
+	// End returns the position of the node's last character.
+	End() Position
+}
+
+// Position is a location in a .nama file. Lines and columns start at 1 and
+// columns count bytes. The zero Position means the location is unknown.
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Span records where a node came from. It is embedded in every node type.
+type Span struct {
+	From Position
+	To   Position
+}
+
+func (s Span) Start() Position {
+	return s.From
+}
+
+func (s Span) End() Position {
+	return s.To
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+// QuoteNode is a block quotation, written quote { ... } or, with an
+// attribution, quote(Ada Lovelace) { ... }. The attribution is inline
+// content, so it may cite a webography entry: quote(${+key}).
+type QuoteNode struct {
+	Span
+	// Content holds the quoted text laid out like that of a TextNode;
+	// Paragraphs holds it parsed.
+	Content     string
+	Paragraphs  []Paragraph
+	Attribution []Inline
+}
+
+func (q *QuoteNode) Kind() NodeKind {
+	return KindQuote
+}
+
+func (q *QuoteNode) Children() []Node {
+	return nil
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// LoadRIS adds the references of an RIS file. Entries without an ID tag get
+// a keyword made of the first author's family name and the year, such as
+// doe2019, so that exports from reference managers can be cited.
+func (b *Webography) LoadRIS(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	var currentEntry *WBibEntry
+	lineNo, entryLine := 0, 0
+
+	for scanner.Scan() {
+		lineNo++
+		tag, value, ok := splitRISLine(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		if tag == "TY" {
+			currentEntry = &WBibEntry{}
+			entryLine = lineNo
+			continue
+		}
+		if currentEntry == nil {
+			return fmt.Errorf("ris: line %d: %s outside of a reference", lineNo, tag)
+		}
+
+		switch tag {
+		case "ID":
+			currentEntry.Keyword = value
+		case "TI", "T1":
+			if currentEntry.Name == "" {
+				currentEntry.Name = value
+			}
+		case "AU", "A1":
+			currentEntry.Authors = append(currentEntry.Authors, value)
+		case "UR", "L2":
+			if currentEntry.URL == "" {
+				currentEntry.URL = value
+			}
+		case "DA":
+			currentEntry.Date = normalizeRISDate(value)
+		case "PY", "Y1":
+			if currentEntry.Date == "" {
+				currentEntry.Date = normalizeRISDate(value)
+			}
+		case "Y2":
+			currentEntry.AccessDate = normalizeRISDate(value)
+		case "PB":
+			currentEntry.Publisher = value
+		case "LA":
+			currentEntry.Language = value
+		case "N1":
+			currentEntry.Note = value
+		case "ER":
+			if currentEntry.Keyword == "" {
+				currentEntry.Keyword = b.risKeyword(currentEntry)
+			}
+			b.load(currentEntry, entryLine)
+			currentEntry = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if currentEntry != nil {
+		return fmt.Errorf("ris: line %d: reference without an ER tag", lineNo)
+	}
+	return nil
+}
+
+// WriteRIS writes the webography as RIS references of type ELEC. RIS has no
+// tag for archived copies, so ArchiveURL is left out.
+func (b *Webography) WriteRIS(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	tag := func(tag, value string) {
+		if value != "" {
+			fmt.Fprintf(bw, "%s  - %s\n", tag, value)
+		}
+	}
+
+	for _, entry := range b.Entries() {
+		tag("TY", "ELEC")
+		tag("ID", entry.Keyword)
+		tag("TI", entry.Name)
+		for _, author := range entry.Authors {
+			tag("AU", author)
+		}
+		tag("UR", entry.URL)
+		if parts := parseDateParts(entry.Date); parts != nil {
+			tag("PY", fmt.Sprintf("%04d", parts[0]))
+		}
+		tag("DA", risDate(entry.Date))
+		tag("Y2", risDate(entry.AccessDate))
+		tag("PB", entry.Publisher)
+		tag("LA", entry.Language)
+		tag("N1", entry.Note)
+		bw.WriteString("ER  - \n\n")
+	}
+
+	return bw.Flush()
+}
+
+// splitRISLine splits a "XY  - value" line. The value may be missing, as it
+// is after ER.
+func splitRISLine(line string) (string, string, bool) {
+	line = strings.TrimRightFunc(line, unicode.IsSpace)
+	if len(line) < 5 || line[2:5] != "  -" {
+		return "", "", false
+	}
+	return line[:2], strings.TrimSpace(line[5:]), true
+}
+
+// normalizeRISDate turns RIS dates such as 2019/04/01/ or 2019/// into the
+// ISO form used elsewhere in the webography.
+func normalizeRISDate(date string) string {
+	if parts := parseDateParts(date); parts != nil {
+		return formatDateParts(parts)
+	}
+	return date
+}
+
+func risDate(date string) string {
+	if parts := parseDateParts(date); parts != nil {
+		return strings.ReplaceAll(formatDateParts(parts), "-", "/")
+	}
+	return date
+}
+
+// risKeyword derives an unused keyword for a reference that has no ID.
+func (b *Webography) risKeyword(entry *WBibEntry) string {
+	base := "ref"
+	if len(entry.Authors) > 0 {
+		family, _, _ := strings.Cut(entry.Authors[0], ",")
+		if fields := strings.Fields(family); len(fields) > 0 {
+			base = strings.ToLower(fields[len(fields)-1])
+		}
+	}
+	if parts := parseDateParts(entry.Date); parts != nil {
+		base += fmt.Sprintf("%04d", parts[0])
+	}
+
+	keyword := base
+	for n := 2; ; n++ {
+		if _, exists := b.entries[keyword]; !exists {
+			return keyword
+		}
+		keyword = fmt.Sprintf("%s-%d", base, n)
+	}
+}
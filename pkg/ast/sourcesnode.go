@@ -18,6 +18,18 @@ along with nanami. If not, see <https://www.gnu.org/licenses/>.
 
 package ast
 
+// SourcesNode is a block listing sources, usually holding {footnotes}.
+// Inline holds its Content parsed.
 type SourcesNode struct {
+	Span
 	Content string
+	Inline  []Inline
+}
+
+func (s *SourcesNode) Kind() NodeKind {
+	return KindSources
+}
+
+func (s *SourcesNode) Children() []Node {
+	return nil
 }
@@ -0,0 +1,80 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+// Alignment is the horizontal alignment of a table column.
+type Alignment int
+
+const (
+	AlignDefault Alignment = iota
+	AlignLeft
+	AlignCenter
+	AlignRight
+)
+
+func (a Alignment) String() string {
+	switch a {
+	case AlignLeft:
+		return "left"
+	case AlignCenter:
+		return "center"
+	case AlignRight:
+		return "right"
+	}
+	return ""
+}
+
+// TableNode is a table, written table { ... } with one row per line, or
+// table(file.csv) { ... } to read the rows from a CSV file.
+type TableNode struct {
+	Span
+	// Source is the CSV file named in table(file), as written.
+	Source  string
+	Caption []Inline
+	// Align holds the alignment of each column; columns past its end use
+	// AlignDefault.
+	Align []Alignment
+	// Header is the header row, or nil if the table has none.
+	Header []TableCell
+	Rows   [][]TableCell
+}
+
+// TableCell is one cell of a table.
+type TableCell struct {
+	// Text is the cell as written; Content holds it parsed.
+	Text    string
+	Content []Inline
+}
+
+func (t *TableNode) Kind() NodeKind {
+	return KindTable
+}
+
+func (t *TableNode) Children() []Node {
+	return nil
+}
+
+// Columns returns the number of columns of the widest row.
+func (t *TableNode) Columns() int {
+	columns := len(t.Header)
+	for _, row := range t.Rows {
+		columns = max(columns, len(row))
+	}
+	return columns
+}
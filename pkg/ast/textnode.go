@@ -18,7 +18,34 @@ along with nanami. If not, see <https://www.gnu.org/licenses/>.
 
 package ast
 
+// TextNode is a block of prose. Content holds its source text with the
+// paragraphs separated by blank lines and a single newline inside a
+// paragraph for a hard line break; Paragraphs holds the same text parsed.
 type TextNode struct {
-	Content string
-	NoNLP   bool
+	Span
+	Content    string
+	Paragraphs []Paragraph
+	// NoNLP is set by text(!nlp): the block is written as it is, without
+	// paragraph markup, which suits hand-written HTML. NLP is set by
+	// text(nlp) and lays the block out as paragraphs even in a document
+	// marked !nlp. With neither set the block follows the document.
+	NoNLP bool
+	NLP   bool
+}
+
+// UsesParagraphs reports whether the block is laid out as paragraphs in
+// doc.
+func (t *TextNode) UsesParagraphs(doc *Document) bool {
+	if t.NLP || t.NoNLP {
+		return t.NLP
+	}
+	return doc == nil || !doc.NoNLP
+}
+
+func (t *TextNode) Kind() NodeKind {
+	return KindText
+}
+
+func (t *TextNode) Children() []Node {
+	return nil
 }
@@ -18,9 +18,19 @@ along with nanami. If not, see <https://www.gnu.org/licenses/>.
 
 package ast
 
+// WBibEntry is a single webography entry. Each field except Authors comes
+// from one tag of the webography format, shown in the comments.
 type WBibEntry struct {
-	Keyword string
-	URL     string
-	Name    string
-	Date    string
+	Keyword string // T:
+	URL     string // L:
+	Name    string // N:
+	Date    string // D:
+	// Authors are kept as "Family, Given" when the name has both parts,
+	// one A: tag each.
+	Authors    []string
+	Publisher  string // P:, the publisher or site name
+	AccessDate string // AD:
+	ArchiveURL string // AR:, an archived copy of URL
+	Language   string // LA:
+	Note       string // NT:
 }
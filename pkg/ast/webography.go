@@ -21,16 +21,46 @@ package ast
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+
+	"github.com/nanomarkdown/nanami/pkg/diag"
+)
+
+// Diagnostic codes reported while loading a webography.
+const (
+	CodeDuplicateKeyword = "duplicate-keyword"
+	CodeStrayField       = "stray-field"
 )
 
 type Webography struct {
 	entries map[string]*WBibEntry
-	ordered []*WBibEntry
-	counter int
+	keys    []string // keywords in the order they were first added
+	origins map[string]Origin
+	diags   diag.List
+	file    string // file being loaded, for origins and diagnostics
+	offset  int    // added to line numbers while loading an embedded block
 }
 
+// Origin records where an entry was loaded from. Line is 0 for formats that
+// have no useful line numbers.
+type Origin struct {
+	File string
+	Line int
+}
+
+func NewWebography() *Webography {
+	return &Webography{
+		entries: map[string]*WBibEntry{},
+		origins: map[string]Origin{},
+	}
+}
+
+// LoadFromFile adds the entries of a webography file. The format follows the
+// extension: .bib is BibTeX, .json CSL-JSON and .ris RIS; anything else is
+// read as T:/L:/N:/D: lines.
 func (b *Webography) LoadFromFile(filename string) error {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -38,76 +68,221 @@ func (b *Webography) LoadFromFile(filename string) error {
 	}
 	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
+	b.file = filename
+	defer func() { b.file = "" }()
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".bib":
+		return b.LoadBibTeX(file)
+	case ".json":
+		return b.LoadCSLJSON(file)
+	case ".ris":
+		return b.LoadRIS(file)
+	}
+	return b.Load(file)
+}
+
+// SaveToFile writes the webography to filename, choosing the format from the
+// extension as LoadFromFile does. BibTeX output is not supported.
+func (b *Webography) SaveToFile(filename string) error {
+	var write func(io.Writer) error
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".bib":
+		return fmt.Errorf("%s: writing BibTeX is not supported", filename)
+	case ".json":
+		write = b.WriteCSLJSON
+	case ".ris":
+		write = b.WriteRIS
+	default:
+		write = b.Write
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Load adds the entries of a webography in the T:/L:/N:/D: line format. Each
+// entry starts with a T: keyword line and may go on with L: (URL), N: (name),
+// D: (date), A: (one per author), P: (publisher or site), AD: (access date),
+// AR: (archived copy), LA: (language) and NT: (note) lines.
+func (b *Webography) Load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
 	var currentEntry *WBibEntry
+	lineNo, entryLine := 0, 0
 
 	for scanner.Scan() {
+		lineNo++
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			if currentEntry != nil && currentEntry.Keyword != "" {
-				b.entries[currentEntry.Keyword] = currentEntry
+				b.load(currentEntry, entryLine)
 				currentEntry = nil
 			}
 			continue
 		}
 
-		if strings.HasPrefix(line, "T: ") {
+		if strings.HasPrefix(line, "T:") {
 			// In case there was no linebreak between entries
 			if currentEntry != nil && currentEntry.Keyword != "" {
-				b.entries[currentEntry.Keyword] = currentEntry
+				b.load(currentEntry, entryLine)
+			}
+			currentEntry = &WBibEntry{Keyword: strings.TrimSpace(line[2:])}
+			entryLine = lineNo
+			if currentEntry.Keyword == "" {
+				b.errorf(lineNo, CodeStrayField, "T: line without a keyword")
 			}
-			currentEntry = &WBibEntry{Keyword: strings.TrimSpace(line[3:])}
-		} else if strings.HasPrefix(line, "L: ") && currentEntry != nil {
+		} else if currentEntry == nil {
+			b.errorf(lineNo, CodeStrayField, "%q is not part of an entry, entries start with T:", line)
+		} else if strings.HasPrefix(line, "L: ") {
 			currentEntry.URL = strings.TrimSpace(line[3:])
-		} else if strings.HasPrefix(line, "N: ") && currentEntry != nil {
+		} else if strings.HasPrefix(line, "N: ") {
 			currentEntry.Name = strings.TrimSpace(line[3:])
-		} else if strings.HasPrefix(line, "D: ") && currentEntry != nil {
+		} else if strings.HasPrefix(line, "D: ") {
 			currentEntry.Date = strings.TrimSpace(line[3:])
+		} else if strings.HasPrefix(line, "A: ") {
+			currentEntry.Authors = append(currentEntry.Authors, strings.TrimSpace(line[3:]))
+		} else if strings.HasPrefix(line, "P: ") {
+			currentEntry.Publisher = strings.TrimSpace(line[3:])
+		} else if strings.HasPrefix(line, "AD: ") {
+			currentEntry.AccessDate = strings.TrimSpace(line[4:])
+		} else if strings.HasPrefix(line, "AR: ") {
+			currentEntry.ArchiveURL = strings.TrimSpace(line[4:])
+		} else if strings.HasPrefix(line, "LA: ") {
+			currentEntry.Language = strings.TrimSpace(line[4:])
+		} else if strings.HasPrefix(line, "NT: ") {
+			currentEntry.Note = strings.TrimSpace(line[4:])
+		} else {
+			b.errorf(lineNo, CodeStrayField, "unknown webography line %q", line)
 		}
 	}
 
 	// Process remaining entry
 	if currentEntry != nil && currentEntry.Keyword != "" {
-		b.entries[currentEntry.Keyword] = currentEntry
+		b.load(currentEntry, entryLine)
 	}
 
 	return scanner.Err()
 }
 
-// TODO: All rendering must be decoupled from ASTs
-func (b *Webography) GetReference(keyword string) string {
-	if entry, exists := b.entries[keyword]; exists {
-		for i, orderedEntry := range b.ordered {
-			if orderedEntry.Keyword == keyword {
-				return fmt.Sprintf(`<sup><a href="#s%[1]d">[%[1]d]</a></sup>`, i+1)
-			}
-		}
+// LoadEmbedded adds the entries of a webography block embedded in a
+// document. firstLine is the document line the block's body starts on, so
+// that origins and diagnostics point into the document.
+func (b *Webography) LoadEmbedded(r io.Reader, firstLine int) error {
+	b.offset = firstLine - 1
+	defer func() { b.offset = 0 }()
+	return b.Load(r)
+}
 
-		b.ordered = append(b.ordered, entry)
-		b.counter++
-		return fmt.Sprintf(`<sup><a href="#s%[1]d">[%[1]d]</a></sup>`, b.counter)
+// Merge adds every entry of other, replacing entries with the same keyword
+// without reporting them as duplicates. The diagnostics of other are kept.
+func (b *Webography) Merge(other *Webography) {
+	for _, entry := range other.Entries() {
+		b.Add(entry)
+		if origin, ok := other.origins[entry.Keyword]; ok {
+			b.origins[entry.Keyword] = origin
+		} else {
+			delete(b.origins, entry.Keyword)
+		}
 	}
-	return ""
+	b.diags = append(b.diags, other.diags...)
 }
 
-func (b *Webography) GenerateFootnotes() string {
-	if len(b.ordered) == 0 {
-		return ""
+// Write writes the webography in the line format that Load reads, one
+// blank-line separated entry at a time.
+func (b *Webography) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	line := func(tag, value string) {
+		if value != "" {
+			fmt.Fprintf(bw, "%s: %s\n", tag, value)
+		}
 	}
 
-	var result strings.Builder
-	result.WriteString("<ol>\n")
+	for i, entry := range b.Entries() {
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		line("T", entry.Keyword)
+		line("L", entry.URL)
+		line("N", entry.Name)
+		line("D", entry.Date)
+		for _, author := range entry.Authors {
+			line("A", author)
+		}
+		line("P", entry.Publisher)
+		line("AD", entry.AccessDate)
+		line("AR", entry.ArchiveURL)
+		line("LA", entry.Language)
+		line("NT", entry.Note)
+	}
+	return bw.Flush()
+}
 
-	for i, entry := range b.ordered {
-		result.WriteString(fmt.Sprintf(`            <li id="s%[1]d">%[1]d. %s, %s`,
-			i+1, entry.Name, entry.Date))
-		if entry.URL != "" {
-			result.WriteString(fmt.Sprintf(` <a href="%[1]s">%[1]s</a>`,
-				entry.URL))
+// Add registers entry under its keyword, replacing any previous entry.
+func (b *Webography) Add(entry *WBibEntry) {
+	if _, exists := b.entries[entry.Keyword]; !exists {
+		b.keys = append(b.keys, entry.Keyword)
+	}
+	b.entries[entry.Keyword] = entry
+}
+
+// load adds an entry read from a file, reporting keywords that were already
+// taken. The later entry wins, as it always has.
+func (b *Webography) load(entry *WBibEntry, line int) {
+	if first, exists := b.origins[entry.Keyword]; exists {
+		where := "earlier"
+		if first.Line > 0 {
+			where = fmt.Sprintf("on line %d", first.Line)
+			if first.File != b.file {
+				where = fmt.Sprintf("at %s:%d", first.File, first.Line)
+			}
 		}
-		result.WriteString("</li>\n")
+		b.errorf(line, CodeDuplicateKeyword, "keyword %q is already defined %s", entry.Keyword, where)
 	}
+	b.Add(entry)
+	b.origins[entry.Keyword] = Origin{File: b.file, Line: line + b.offset}
+}
+
+func (b *Webography) errorf(line int, code, format string, args ...any) {
+	b.diags = append(b.diags, diag.Diagnostic{
+		File:     b.file,
+		Line:     line + b.offset,
+		Column:   1,
+		Severity: diag.Error,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Origin returns where the entry for keyword was loaded from, if it came
+// from a file.
+func (b *Webography) Origin(keyword string) (Origin, bool) {
+	origin, ok := b.origins[keyword]
+	return origin, ok
+}
+
+// Diagnostics returns the problems found while loading the webography.
+func (b *Webography) Diagnostics() diag.List {
+	return b.diags
+}
+
+// Entries returns every entry in the order it was first added.
+func (b *Webography) Entries() []*WBibEntry {
+	entries := make([]*WBibEntry, len(b.keys))
+	for i, key := range b.keys {
+		entries[i] = b.entries[key]
+	}
+	return entries
+}
 
-	result.WriteString("        </ol>")
-	return result.String()
+// Lookup returns the entry registered under keyword, if any.
+func (b *Webography) Lookup(keyword string) (*WBibEntry, bool) {
+	entry, exists := b.entries[keyword]
+	return entry, exists
 }
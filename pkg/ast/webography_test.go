@@ -0,0 +1,140 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package ast
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func sampleWebography() *Webography {
+	bib := NewWebography()
+	bib.Add(&WBibEntry{
+		Keyword:    "smthiread",
+		URL:        "https://example.com/whatever.xhtml",
+		Name:       "Something I Read",
+		Date:       "2019-04-01",
+		Authors:    []string{"Doe, Jane", "Example Collective"},
+		Publisher:  "Example Site",
+		AccessDate: "2024-01-02",
+		ArchiveURL: "https://web.archive.org/web/2024/https://example.com/whatever.xhtml",
+		Language:   "en",
+		Note:       "Second edition.",
+	})
+	bib.Add(&WBibEntry{
+		Keyword: "anthrthngiread",
+		URL:     "https://example.com/sowhat.xhtml",
+		Name:    "Another Thing I Read",
+		Date:    "Spring 2020",
+	})
+	return bib
+}
+
+func TestWebographyRoundTrips(t *testing.T) {
+	formats := []struct {
+		name  string
+		write func(*Webography, io.Writer) error
+		load  func(*Webography, io.Reader) error
+		// lost removes from an entry what the format cannot hold.
+		lost func(*WBibEntry)
+	}{
+		{"native", (*Webography).Write, (*Webography).Load, nil},
+		{"csl-json", (*Webography).WriteCSLJSON, (*Webography).LoadCSLJSON, nil},
+		{"ris", (*Webography).WriteRIS, (*Webography).LoadRIS, func(e *WBibEntry) {
+			e.ArchiveURL = ""
+		}},
+	}
+
+	for _, format := range formats {
+		want := sampleWebography()
+		if format.lost != nil {
+			for _, entry := range want.Entries() {
+				format.lost(entry)
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := format.write(want, &buf); err != nil {
+			t.Fatalf("%s: write returned %v", format.name, err)
+		}
+
+		got := NewWebography()
+		if err := format.load(got, &buf); err != nil {
+			t.Fatalf("%s: load returned %v", format.name, err)
+		}
+		if !reflect.DeepEqual(got.Entries(), want.Entries()) {
+			t.Errorf("%s: entries did not survive a round trip:\n%+v\n%+v",
+				format.name, got.Entries()[0], want.Entries()[0])
+		}
+	}
+}
+
+func TestLoadRISWithoutIDs(t *testing.T) {
+	bib := NewWebography()
+	err := bib.LoadRIS(strings.NewReader(`TY  - ELEC
+T1  - A Page
+AU  - Doe, Jane
+PY  - 2019///
+UR  - https://example.com/a
+ER  - 
+
+TY  - ELEC
+TI  - Another Page
+A1  - Jane Doe
+DA  - 2019/05/06/
+ER  -
+`))
+	if err != nil {
+		t.Fatalf("LoadRIS returned %v", err)
+	}
+
+	var keywords []string
+	for _, entry := range bib.Entries() {
+		keywords = append(keywords, entry.Keyword)
+	}
+	if want := []string{"doe2019", "doe2019-2"}; !reflect.DeepEqual(keywords, want) {
+		t.Errorf("Expected keywords %v, got %v", want, keywords)
+	}
+	if entry, _ := bib.Lookup("doe2019-2"); entry.Date != "2019-05-06" {
+		t.Errorf("Expected the DA date to be normalised, got %q", entry.Date)
+	}
+}
+
+func TestLoadCSLJSONNumericIDs(t *testing.T) {
+	bib := NewWebography()
+	err := bib.LoadCSLJSON(strings.NewReader(`[
+		{"id": 42, "type": "webpage", "title": "Numbered",
+		 "author": [{"family": "Doe", "given": "Jane"}, {"literal": "NASA"}],
+		 "issued": {"date-parts": [["2021", 3]]}}
+	]`))
+	if err != nil {
+		t.Fatalf("LoadCSLJSON returned %v", err)
+	}
+
+	entry, ok := bib.Lookup("42")
+	if !ok {
+		t.Fatalf("Expected an entry keyed by the numeric id")
+	}
+	if entry.Date != "2021-03" || !reflect.DeepEqual(entry.Authors, []string{"Doe, Jane", "NASA"}) {
+		t.Errorf("Unexpected entry %+v", *entry)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package check looks for mistakes in a parsed document and its webography
+// that the parser cannot see on its own.
+package check
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+	"github.com/nanomarkdown/nanami/pkg/diag"
+)
+
+// Diagnostic codes reported by Document.
+const (
+	CodeUnresolvedReference = "unresolved-reference"
+	CodeUnusedEntry         = "unused-entry"
+	CodeMissingField        = "missing-field"
+	CodeMalformedURL        = "malformed-url"
+)
+
+// Document checks the references of doc against its webography. The result
+// starts with the problems found while loading the webography, followed by
+// references to unknown keywords and {ref:id} references to unknown
+// figures, then problems with the entries themselves: missing names or
+// dates, malformed URLs and entries that are never cited. Diagnostics about
+// the document carry no file name; those about entries name the file they
+// were loaded from.
+func Document(doc *ast.Document) diag.List {
+	bib := doc.Webography
+	if bib == nil {
+		bib = ast.NewWebography()
+	}
+
+	diags := append(diag.List(nil), bib.Diagnostics()...)
+	cited := map[string]bool{}
+	figures := ast.Figures(doc)
+
+	walk(doc, func(n ast.Node, content []ast.Inline) {
+		for _, keyword := range references(content) {
+			if _, ok := bib.Lookup(keyword); !ok {
+				diags.Errorf(n.Start().Line, n.Start().Column, CodeUnresolvedReference,
+					"reference to unknown webography entry %q", keyword)
+			}
+			cited[keyword] = true
+		}
+		ast.WalkInline(content, func(in ast.Inline) {
+			if ref, ok := in.(*ast.Ref); ok && figures[ref.ID] == nil {
+				diags.Errorf(n.Start().Line, n.Start().Column, CodeUnresolvedReference,
+					"reference to unknown figure %q", ref.ID)
+			}
+		})
+	})
+
+	for _, entry := range bib.Entries() {
+		report := func(sev diag.Severity, code, format string, args ...any) {
+			origin, _ := bib.Origin(entry.Keyword)
+			d := diag.Diagnostic{
+				File:     origin.File,
+				Line:     origin.Line,
+				Severity: sev,
+				Code:     code,
+				Message:  fmt.Sprintf(format, args...),
+			}
+			if d.Line > 0 {
+				d.Column = 1
+			}
+			diags = append(diags, d)
+		}
+
+		if entry.Name == "" {
+			report(diag.Warning, CodeMissingField, "entry %q has no name (N:)", entry.Keyword)
+		}
+		if entry.Date == "" {
+			report(diag.Warning, CodeMissingField, "entry %q has no date (D:)", entry.Keyword)
+		}
+		for _, link := range []struct{ tag, url string }{
+			{"L", entry.URL},
+			{"AR", entry.ArchiveURL},
+		} {
+			if link.url != "" && !validURL(link.url) {
+				report(diag.Error, CodeMalformedURL, "entry %q has a malformed URL in %s: %q",
+					entry.Keyword, link.tag, link.url)
+			}
+		}
+		if !cited[entry.Keyword] {
+			report(diag.Warning, CodeUnusedEntry, "entry %q is never cited", entry.Keyword)
+		}
+	}
+
+	return diags
+}
+
+// walk calls visit for every node holding inline content.
+func walk(n ast.Node, visit func(n ast.Node, content []ast.Inline)) {
+	if content := ast.InlineContent(n); content != nil {
+		visit(n, content)
+	}
+	for _, child := range n.Children() {
+		walk(child, visit)
+	}
+}
+
+// references returns the keywords cited in content.
+func references(content []ast.Inline) []string {
+	var keywords []string
+	ast.WalkInline(content, func(in ast.Inline) {
+		if citation, ok := in.(*ast.Citation); ok {
+			for _, item := range citation.Items {
+				keywords = append(keywords, item.Keyword)
+			}
+		}
+	})
+	return keywords
+}
+
+func validURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
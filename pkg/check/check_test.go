@@ -0,0 +1,107 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package check
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+	"github.com/nanomarkdown/nanami/pkg/parser"
+)
+
+func TestDocument(t *testing.T) {
+	bib := ast.NewWebography()
+	err := bib.Load(strings.NewReader(`T: cited
+L: https://example.com/a
+N: Cited
+D: 2019
+
+T: broken
+L: example.com/b
+AR: https://web.archive.org/b
+
+X: stray
+
+T: cited
+L: https://example.com/a
+N: Cited Again
+D: 2020
+`))
+	if err != nil {
+		t.Fatalf("Load returned %v", err)
+	}
+
+	doc := &ast.Document{
+		Content: []ast.Node{
+			&ast.TextNode{
+				Span:       ast.Span{From: ast.Position{Line: 3, Column: 2}},
+				Paragraphs: parser.ParseParagraphs("${cited} and ${typo} ${broken}"),
+			},
+		},
+		Webography: bib,
+	}
+
+	var got []string
+	for _, d := range Document(doc) {
+		got = append(got, d.Error())
+	}
+	want := []string{
+		`10:1: "X: stray" is not part of an entry, entries start with T: [stray-field]`,
+		`12:1: keyword "cited" is already defined on line 1 [duplicate-keyword]`,
+		`3:2: reference to unknown webography entry "typo" [unresolved-reference]`,
+		`6:1: warning: entry "broken" has no name (N:) [missing-field]`,
+		`6:1: warning: entry "broken" has no date (D:) [missing-field]`,
+		`6:1: entry "broken" has a malformed URL in L: "example.com/b" [malformed-url]`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected diagnostics:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestDocumentUnusedEntries(t *testing.T) {
+	bib := ast.NewWebography()
+	bib.Add(&ast.WBibEntry{Keyword: "unused", Name: "Unused", Date: "2020"})
+
+	diags := Document(&ast.Document{Webography: bib})
+	if len(diags) != 1 || diags[0].Code != CodeUnusedEntry {
+		t.Errorf("Expected a single unused-entry warning, got %v", diags)
+	}
+}
+
+func TestDocumentFigureRefs(t *testing.T) {
+	doc := &ast.Document{
+		Content: []ast.Node{
+			&ast.FigureNode{ID: "arch", Number: 1},
+			&ast.TextNode{
+				Span:       ast.Span{From: ast.Position{Line: 5, Column: 2}},
+				Paragraphs: parser.ParseParagraphs("See {ref:arch} and {ref:missing}."),
+			},
+		},
+	}
+
+	var got []string
+	for _, d := range Document(doc) {
+		got = append(got, d.Error())
+	}
+	want := `5:2: reference to unknown figure "missing" [unresolved-reference]`
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
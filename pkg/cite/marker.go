@@ -0,0 +1,175 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package cite
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+)
+
+// Ref is one entry cited by an in-text citation.
+type Ref struct {
+	Entry   *ast.WBibEntry
+	N       int
+	Locator string
+}
+
+// splitMarker splits the marker template into the literal text before its
+// first placeholder, the literal text after its last one and the template
+// in between. Grouped citations repeat the middle part: "[{n}]" cites two
+// entries as "[1, 2]" and "({author}, {year})" as "(Doe, 2019; Roe, 2020)".
+func (s *Style) splitMarker() {
+	t := s.marker
+	s.open, s.close = "", ""
+	if len(t) > 0 && t[0].field == "" {
+		s.open, t = t[0].literal, t[1:]
+	}
+	if len(t) > 0 && t[len(t)-1].field == "" {
+		s.close, t = t[len(t)-1].literal, t[:len(t)-1]
+	}
+	s.itemTemplate = t
+
+	// A style derived from another one keeps its separator only when it
+	// was given explicitly; otherwise the default follows the final marker.
+	if !s.separatorSet {
+		s.Separator = "; "
+		if s.numbersOnly() {
+			s.Separator = ", "
+		}
+	}
+}
+
+// numbersOnly reports whether each item of the marker is just its number,
+// which lets runs of numbers be shortened to ranges.
+func (s *Style) numbersOnly() bool {
+	var fieldsUsed []string
+	for _, seg := range s.itemTemplate {
+		if seg.field != "" && seg.field != "locator" {
+			fieldsUsed = append(fieldsUsed, seg.field)
+		} else if seg.field == "" && strings.TrimSpace(seg.literal) != "" {
+			return false
+		}
+	}
+	return len(fieldsUsed) == 1 && fieldsUsed[0] == "n"
+}
+
+// Cite formats an in-text citation of refs. link turns the text standing
+// for the entry numbered n into a link to it; wrap decorates fields as in
+// Entry. A single plain citation is linked as a whole so that "[1]" stays
+// one link; grouped citations link each item. Items that come out empty,
+// such as an author-only marker with the author suppressed, are left out
+// along with the brackets around them.
+func (s *Style) Cite(refs []Ref, mode ast.CitationMode,
+	link func(n int, text string) string, wrap func(field, value string) string) string {
+	if len(refs) == 0 {
+		return ""
+	}
+
+	if mode == ast.CiteNarrative {
+		parts := make([]string, len(refs))
+		for i, ref := range refs {
+			label := wrap("author", authorLabel(ref.Entry))
+			if item := s.citeItem(ref, true, wrap); item != "" {
+				parts[i] = label + " " + link(ref.N, s.open+item+s.close)
+			} else {
+				parts[i] = link(ref.N, label)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+
+	suppress := mode == ast.CiteSuppressAuthor
+	if len(refs) == 1 {
+		// An empty item still gets its link, so that the bibliography can
+		// link back to the citation.
+		if item := s.citeItem(refs[0], suppress, wrap); item != "" {
+			return link(refs[0].N, s.open+item+s.close)
+		}
+		return link(refs[0].N, "")
+	}
+
+	var parts []string
+	if s.numbersOnly() && !hasLocators(refs) {
+		parts = numberRanges(refs, link)
+	} else {
+		for _, ref := range refs {
+			if item := s.citeItem(ref, suppress, wrap); item != "" {
+				parts = append(parts, link(ref.N, item))
+			}
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return s.open + strings.Join(parts, s.Separator) + s.close
+}
+
+// citeItem expands the item template for ref, dropping the blanks that
+// separate a suppressed author from what follows it.
+func (s *Style) citeItem(ref Ref, suppressAuthor bool, wrap func(field, value string) string) string {
+	return strings.TrimSpace(s.itemTemplate.execute(s.fieldFunc(ref, suppressAuthor), wrap))
+}
+
+func hasLocators(refs []Ref) bool {
+	for _, ref := range refs {
+		if ref.Locator != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// numberRanges sorts the numbers of refs and joins runs of three or more
+// into ranges: 1, 2, 3 and 5 become "1–3" and "5".
+func numberRanges(refs []Ref, link func(n int, text string) string) []string {
+	var numbers []int
+	seen := map[int]bool{}
+	for _, ref := range refs {
+		if !seen[ref.N] {
+			seen[ref.N] = true
+			numbers = append(numbers, ref.N)
+		}
+	}
+	sort.Ints(numbers)
+
+	num := func(n int) string {
+		return link(n, strconv.Itoa(n))
+	}
+
+	var parts []string
+	for i := 0; i < len(numbers); {
+		j := i
+		for j+1 < len(numbers) && numbers[j+1] == numbers[j]+1 {
+			j++
+		}
+		switch {
+		case j-i >= 2:
+			parts = append(parts, num(numbers[i])+"–"+num(numbers[j]))
+		case j > i:
+			parts = append(parts, num(numbers[i]), num(numbers[j]))
+		default:
+			parts = append(parts, num(numbers[i]))
+		}
+		i = j + 1
+	}
+	return parts
+}
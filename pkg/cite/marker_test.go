@@ -0,0 +1,88 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package cite
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+)
+
+func TestCite(t *testing.T) {
+	doe := &ast.WBibEntry{Name: "A", Date: "2019", Authors: []string{"Doe, Jane"}}
+	roe := &ast.WBibEntry{Name: "B", Date: "2020", Authors: []string{"Roe, Rick"}}
+	link := func(n int, text string) string {
+		return fmt.Sprintf("<%d:%s>", n, text)
+	}
+
+	tests := []struct {
+		style string
+		refs  []Ref
+		mode  ast.CitationMode
+		want  string
+	}{
+		{"numeric", []Ref{{doe, 1, ""}}, ast.CiteNormal, "<1:[1]>"},
+		{"numeric", []Ref{{doe, 1, "p. 12"}}, ast.CiteNormal, "<1:[1, p. 12]>"},
+		{"numeric", []Ref{{doe, 3, ""}, {doe, 1, ""}, {doe, 2, ""}}, ast.CiteNormal, "[<1:1>–<3:3>]"},
+		{"numeric", []Ref{{doe, 4, ""}, {doe, 1, ""}, {doe, 1, ""}}, ast.CiteNormal, "[<1:1>, <4:4>]"},
+		{"numeric", []Ref{{doe, 1, ""}, {doe, 2, ""}, {doe, 5, ""}}, ast.CiteNormal, "[<1:1>, <2:2>, <5:5>]"},
+		{"numeric", []Ref{{doe, 2, "p. 1"}, {roe, 1, ""}}, ast.CiteNormal, "[<2:2, p. 1>, <1:1>]"},
+		{"chicago", []Ref{{doe, 1, ""}, {roe, 2, ""}}, ast.CiteNormal, "<1:1>,<2:2>"},
+		{"chicago", []Ref{{doe, 3, "p. 12"}}, ast.CiteNormal, "<3:3, p. 12>"},
+		{"apa", []Ref{{doe, 1, ""}, {roe, 2, "ch. 3"}}, ast.CiteNormal, "(<1:Doe, 2019>; <2:Roe, 2020, ch. 3>)"},
+		{"apa", []Ref{{doe, 1, ""}}, ast.CiteSuppressAuthor, "<1:(2019)>"},
+		{"apa", []Ref{{doe, 1, "p. 4"}}, ast.CiteNarrative, "Doe <1:(2019, p. 4)>"},
+		{"numeric", []Ref{{doe, 1, ""}, {roe, 2, ""}}, ast.CiteNarrative, "Doe <1:[1]>, Roe <2:[2]>"},
+		{"mla", []Ref{{doe, 1, "12"}}, ast.CiteNormal, "<1:(Doe 12)>"},
+		{"mla", []Ref{{doe, 1, "12"}}, ast.CiteSuppressAuthor, "<1:(12)>"},
+		{"mla", []Ref{{doe, 1, ""}}, ast.CiteSuppressAuthor, "<1:>"},
+		{"mla", []Ref{{doe, 1, ""}, {roe, 2, ""}}, ast.CiteSuppressAuthor, ""},
+		{"mla", []Ref{{doe, 1, ""}}, ast.CiteNarrative, "<1:Doe>"},
+		{"mla", []Ref{{doe, 1, "12"}}, ast.CiteNarrative, "Doe <1:(12)>"},
+	}
+
+	for _, test := range tests {
+		got := Builtin(test.style).Cite(test.refs, test.mode, link, plain)
+		if got != test.want {
+			t.Errorf("%s %v: expected %q, got %q", test.style, test.refs, test.want, got)
+		}
+	}
+}
+
+func TestCiteDerivedSeparator(t *testing.T) {
+	doe := &ast.WBibEntry{Date: "2019", Authors: []string{"Doe, Jane"}}
+	roe := &ast.WBibEntry{Date: "2020", Authors: []string{"Roe, Rick"}}
+	refs := []Ref{{doe, 1, ""}, {roe, 2, ""}}
+
+	for src, want := range map[string]string{
+		"base: numeric\nmarker: ({author}, {year})":                     "(Doe, 2019; Roe, 2020)",
+		"base: numeric\nmarker: ({author}, {year})\nseparator: \" / \"": "(Doe, 2019 / Roe, 2020)",
+		"base: chicago\nentry: {title}":                                 "1,2",
+	} {
+		style, err := ParseStyle(strings.NewReader(src))
+		if err != nil {
+			t.Fatalf("ParseStyle(%q) returned %v", src, err)
+		}
+		if got := style.Cite(refs, ast.CiteNormal, func(n int, text string) string { return text }, plain); got != want {
+			t.Errorf("%q: expected %q, got %q", src, want, got)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package cite formats webography entries according to citation styles,
+// both as in-text markers and as bibliography entries.
+package cite
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+)
+
+// Order says how a bibliography is sorted.
+type Order int
+
+const (
+	// OrderCited lists entries in the order they were first cited.
+	OrderCited Order = iota
+	// OrderAuthor lists entries alphabetically by author, then title.
+	OrderAuthor
+)
+
+// Names says how author names kept as "Family, Given" are written out.
+type Names int
+
+const (
+	NamesAsIs       Names = iota // Doe, Jane
+	NamesGivenFirst              // Jane Doe
+	NamesInitials                // J. Doe
+)
+
+// Style describes how citations of webography entries look.
+type Style struct {
+	Name string
+	// Superscript sets in-text markers as superscripts.
+	Superscript bool
+	Order       Order
+	Names       Names
+	// Separator goes between the items of a grouped citation. Unless set
+	// with separator:, it follows from the marker; see splitMarker.
+	Separator string
+	// separatorSet records that separator: gave Separator explicitly.
+	separatorSet bool
+
+	marker template
+	entry  template
+
+	// The marker split into the brackets around a grouped citation and the
+	// part repeated for each item; see splitMarker.
+	open, item, close string
+	itemTemplate      template
+}
+
+// Fields that templates may refer to. n is the number the renderer gave the
+// entry; author is the short author label used in author-date markers and
+// locator the page or section a citation points at. creator is the authors,
+// or the title for anonymous works, and work the title unless creator
+// already holds it.
+var fields = []string{
+	"n", "keyword", "title", "url", "date", "year", "accessed", "authors", "author",
+	"creator", "work", "publisher", "archive", "language", "note", "locator",
+}
+
+func knownField(name string) bool {
+	for _, field := range fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Marker formats the in-text citation of entry, which was numbered n.
+func (s *Style) Marker(entry *ast.WBibEntry, n int, wrap func(field, value string) string) string {
+	return s.marker.execute(s.fieldFunc(Ref{Entry: entry, N: n}, false), wrap)
+}
+
+// Entry formats the bibliography entry of entry, which was numbered n.
+func (s *Style) Entry(entry *ast.WBibEntry, n int, wrap func(field, value string) string) string {
+	return s.entry.execute(s.fieldFunc(Ref{Entry: entry, N: n}, false), wrap)
+}
+
+// Sort orders a bibliography in place according to the style.
+func (s *Style) Sort(entries []*ast.WBibEntry) {
+	if s.Order != OrderAuthor {
+		return
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := sortKey(entries[i]), sortKey(entries[j])
+		return a < b
+	})
+}
+
+// sortKey sorts entries by author, then title. Anonymous works sort by
+// their title, which takes the place of the author.
+func sortKey(entry *ast.WBibEntry) string {
+	lead := strings.Join(entry.Authors, " ")
+	if lead == "" {
+		lead = entry.Name
+	}
+	return strings.ToLower(lead + "\x00" + entry.Name)
+}
+
+// fieldFunc looks up the fields of a cited entry. suppressAuthor blanks the
+// author label for citations whose author is named in the text.
+func (s *Style) fieldFunc(ref Ref, suppressAuthor bool) func(string) string {
+	entry := ref.Entry
+	return func(field string) string {
+		switch field {
+		case "n":
+			return strconv.Itoa(ref.N)
+		case "locator":
+			return ref.Locator
+		case "keyword":
+			return entry.Keyword
+		case "title":
+			return entry.Name
+		case "url":
+			return entry.URL
+		case "date":
+			return entry.Date
+		case "year":
+			return year(entry.Date)
+		case "accessed":
+			return entry.AccessDate
+		case "publisher":
+			return entry.Publisher
+		case "archive":
+			return entry.ArchiveURL
+		case "language":
+			return entry.Language
+		case "note":
+			return entry.Note
+		case "authors":
+			return s.authors(entry.Authors)
+		case "creator":
+			if len(entry.Authors) == 0 {
+				return entry.Name
+			}
+			return s.authors(entry.Authors)
+		case "work":
+			if len(entry.Authors) == 0 {
+				return ""
+			}
+			return entry.Name
+		case "author":
+			if suppressAuthor {
+				return ""
+			}
+			return authorLabel(entry)
+		}
+		return ""
+	}
+}
+
+// year picks the first four digit number out of a free-form date.
+func year(date string) string {
+	for i := 0; i+4 <= len(date); i++ {
+		if _, err := strconv.Atoi(date[i : i+4]); err == nil {
+			return date[i : i+4]
+		}
+	}
+	return date
+}
+
+func (s *Style) authors(authors []string) string {
+	names := make([]string, len(authors))
+	for i, author := range authors {
+		names[i] = s.name(author)
+	}
+
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " and " + names[1]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + ", and " + names[len(names)-1]
+}
+
+func (s *Style) name(author string) string {
+	family, given, found := strings.Cut(author, ", ")
+	if !found || s.Names == NamesAsIs {
+		return author
+	}
+	if s.Names == NamesGivenFirst {
+		return given + " " + family
+	}
+
+	var initials []string
+	for _, part := range strings.Fields(given) {
+		initials = append(initials, string([]rune(part)[0])+".")
+	}
+	return strings.Join(initials, " ") + " " + family
+}
+
+// authorLabel names the authors of entry in a marker, falling back to the
+// title for anonymous works.
+func authorLabel(entry *ast.WBibEntry) string {
+	if label := shortAuthors(entry.Authors); label != "" {
+		return label
+	}
+	return entry.Name
+}
+
+// shortAuthors returns family names for author-date markers: "Doe",
+// "Doe and Roe" or "Doe et al.".
+func shortAuthors(authors []string) string {
+	family := func(author string) string {
+		name, _, _ := strings.Cut(author, ", ")
+		return name
+	}
+
+	switch len(authors) {
+	case 0:
+		return ""
+	case 1:
+		return family(authors[0])
+	case 2:
+		return family(authors[0]) + " and " + family(authors[1])
+	}
+	return family(authors[0]) + " et al."
+}
+
+// Default is the style used when a document does not pick one. It matches
+// the footnotes nanami has always produced.
+const Default = "numeric"
+
+var builtins = map[string]string{
+	"numeric": `
+name: numeric
+marker: [{n}{locator|, |}]
+superscript: true
+entry: {n}. {authors||, }{title}{language| [|]}, {publisher||, }{date}{url| }{archive| (archived: |)}{accessed| (accessed |)}{note|. |}
+`,
+	"ieee": `
+name: ieee
+marker: [{n}{locator|, |}]
+entry: [{n}] {authors||, }"{title}{language| [|]}," {publisher||, }{date}.{url| [Online]. Available: }{accessed| [Accessed: |]}{archive| Archived: |.}{note| |}
+names: initials
+`,
+	"apa": `
+name: apa
+marker: ({author||, }{year}{locator|, |})
+entry: {creator|| }({year}){work|. |}{language| [|]}.{publisher| |.}{url| }{accessed| (retrieved |)}{archive| (archived at |)}{note| |}
+order: author
+`,
+	"chicago": `
+name: chicago
+marker: {n}{locator|, |}
+separator: ","
+superscript: true
+entry: {n}. {authors||, }"{title}{language| [|]},"{publisher| |,} {date}{accessed|, accessed }{url|, }{archive|, archived at }.{note| |}
+names: given-first
+`,
+	"mla": `
+name: mla
+marker: ({author}{locator| |})
+entry: {authors||. }"{title}{language| [|]}."{publisher| |,} {date}{url|, }{archive|, archived at }{accessed|. Accessed |}.{note| |}
+order: author
+`,
+}
+
+// Builtin returns the built-in style called name, or the default style if
+// there is none.
+func Builtin(name string) *Style {
+	src, ok := builtins[name]
+	if !ok {
+		src = builtins[Default]
+	}
+	style, err := ParseStyle(strings.NewReader(src))
+	if err != nil {
+		panic("cite: bad built-in style " + name + ": " + err.Error())
+	}
+	return style
+}
+
+func IsBuiltin(name string) bool {
+	_, ok := builtins[name]
+	return ok
+}
+
+// BuiltinNames lists the built-in styles.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns the built-in style called nameOrPath or, failing that, reads a
+// style definition from the file at that path.
+func Load(nameOrPath string) (*Style, error) {
+	if IsBuiltin(nameOrPath) {
+		return Builtin(nameOrPath), nil
+	}
+
+	file, err := os.Open(nameOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("unknown citation style %q (built-in styles: %s)",
+			nameOrPath, strings.Join(BuiltinNames(), ", "))
+	}
+	defer file.Close()
+
+	style, err := ParseStyle(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", nameOrPath, err)
+	}
+	return style, nil
+}
+
+// ParseStyle reads a style definition: "key: value" lines, with blank lines
+// and lines starting with # ignored. The keys are
+//
+//	base:        built-in style to start from; it must come first
+//	name:        name of the style
+//	marker:      template of the in-text marker
+//	entry:       template of a bibliography entry
+//	superscript: true to set markers as superscripts
+//	order:       cited or author
+//	names:       as-is, given-first or initials
+//	separator:   text between the items of a grouped citation
+//
+// Values may be double-quoted to keep surrounding blanks. Templates use
+// {field} or {field|prefix|suffix} placeholders; the fields are n, keyword,
+// title, url, date, year, accessed, authors, author, creator, work,
+// publisher, archive, language, note and locator.
+func ParseStyle(r io.Reader) (*Style, error) {
+	style := &Style{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	keys := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected key: value", lineNo)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		var err error
+		if strings.HasPrefix(value, `"`) {
+			if value, err = strconv.Unquote(value); err != nil {
+				return nil, fmt.Errorf("line %d: bad quoted value: %w", lineNo, err)
+			}
+		}
+
+		keys++
+		switch key {
+		case "base":
+			switch {
+			case keys > 1:
+				err = fmt.Errorf("base must come before the other keys")
+			case !IsBuiltin(value):
+				err = fmt.Errorf("unknown built-in style %q", value)
+			default:
+				*style = *Builtin(value)
+			}
+		case "name":
+			style.Name = value
+		case "marker":
+			style.marker, err = parseTemplate(value)
+		case "entry":
+			style.entry, err = parseTemplate(value)
+		case "separator":
+			style.Separator, style.separatorSet = value, true
+		case "superscript":
+			style.Superscript, err = strconv.ParseBool(value)
+		case "order":
+			switch value {
+			case "cited":
+				style.Order = OrderCited
+			case "author":
+				style.Order = OrderAuthor
+			default:
+				err = fmt.Errorf("order must be cited or author, got %q", value)
+			}
+		case "names":
+			switch value {
+			case "as-is":
+				style.Names = NamesAsIs
+			case "given-first":
+				style.Names = NamesGivenFirst
+			case "initials":
+				style.Names = NamesInitials
+			default:
+				err = fmt.Errorf("names must be as-is, given-first or initials, got %q", value)
+			}
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if style.marker == nil || style.entry == nil {
+		return nil, fmt.Errorf("a style needs both a marker and an entry template")
+	}
+	style.splitMarker()
+	return style, nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package cite
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+)
+
+var entry = &ast.WBibEntry{
+	Keyword:    "smthiread",
+	URL:        "https://example.com/whatever.xhtml",
+	Name:       "Something I Read",
+	Date:       "2019-04-01",
+	Authors:    []string{"Doe, Jane Ann", "Roe, Richard"},
+	Publisher:  "Example Site",
+	AccessDate: "2024-01-02",
+	ArchiveURL: "https://archive.example/whatever",
+	Language:   "en",
+	Note:       "Revised.",
+}
+
+func plain(field, value string) string {
+	return value
+}
+
+func TestBuiltinStyles(t *testing.T) {
+	tests := []struct {
+		style  string
+		marker string
+		entry  string
+	}{
+		{"numeric", "[3]", "3. Doe, Jane Ann and Roe, Richard, Something I Read [en], Example Site, 2019-04-01 https://example.com/whatever.xhtml (archived: https://archive.example/whatever) (accessed 2024-01-02). Revised."},
+		{"ieee", "[3]", `[3] J. A. Doe and R. Roe, "Something I Read [en]," Example Site, 2019-04-01. [Online]. Available: https://example.com/whatever.xhtml [Accessed: 2024-01-02] Archived: https://archive.example/whatever. Revised.`},
+		{"apa", "(Doe and Roe, 2019)", "Doe, Jane Ann and Roe, Richard (2019). Something I Read [en]. Example Site. https://example.com/whatever.xhtml (retrieved 2024-01-02) (archived at https://archive.example/whatever) Revised."},
+		{"chicago", "3", `3. Jane Ann Doe and Richard Roe, "Something I Read [en]," Example Site, 2019-04-01, accessed 2024-01-02, https://example.com/whatever.xhtml, archived at https://archive.example/whatever. Revised.`},
+		{"mla", "(Doe and Roe)", `Doe, Jane Ann and Roe, Richard. "Something I Read [en]." Example Site, 2019-04-01, https://example.com/whatever.xhtml, archived at https://archive.example/whatever. Accessed 2024-01-02. Revised.`},
+	}
+
+	for _, test := range tests {
+		style := Builtin(test.style)
+		if got := style.Marker(entry, 3, plain); got != test.marker {
+			t.Errorf("%s marker: expected %q, got %q", test.style, test.marker, got)
+		}
+		if got := style.Entry(entry, 3, plain); got != test.entry {
+			t.Errorf("%s entry: expected %q, got %q", test.style, test.entry, got)
+		}
+	}
+}
+
+func TestAPAAnonymousEntry(t *testing.T) {
+	anonymous := &ast.WBibEntry{Name: "C work", Date: "2021", Publisher: "Example Site"}
+	want := "C work (2021). Example Site."
+	if got := Builtin("apa").Entry(anonymous, 1, plain); got != want {
+		t.Errorf("Expected entry %q, got %q", want, got)
+	}
+}
+
+func TestSortAnonymousByTitle(t *testing.T) {
+	entries := []*ast.WBibEntry{
+		{Name: "Zeta anonymous"},
+		{Name: "Beta", Authors: []string{"Doe, Jane"}},
+		{Name: "Alpha anonymous"},
+	}
+	Builtin("apa").Sort(entries)
+
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name)
+	}
+	want := "Alpha anonymous, Beta, Zeta anonymous"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("Expected order %q, got %q", want, strings.Join(got, ", "))
+	}
+}
+
+func TestDefaultStyleKeepsLegacyFootnotes(t *testing.T) {
+	legacy := &ast.WBibEntry{Keyword: "a", URL: "https://example.com", Name: "A Page", Date: "2019"}
+	want := "1. A Page, 2019 https://example.com"
+	if got := Builtin(Default).Entry(legacy, 1, plain); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	style, err := ParseStyle(strings.NewReader(`
+# House style: numeric markers, links first.
+base: ieee
+name: house
+entry: {{{n}}} {url|<|>} {title}
+order: author
+`))
+	if err != nil {
+		t.Fatalf("ParseStyle returned %v", err)
+	}
+
+	if style.Name != "house" || style.Order != OrderAuthor || style.Names != NamesInitials {
+		t.Errorf("Unexpected style %+v", style)
+	}
+	if got := style.Marker(entry, 1, plain); got != "[1]" {
+		t.Errorf("Expected the marker of the base style, got %q", got)
+	}
+	want := "{1} <https://example.com/whatever.xhtml> Something I Read"
+	if got := style.Entry(entry, 1, plain); got != want {
+		t.Errorf("Expected entry %q, got %q", want, got)
+	}
+}
+
+func TestParseStyleErrors(t *testing.T) {
+	for _, src := range []string{
+		"marker: [{n}]",
+		"marker: [{n}]\nentry: {nope}",
+		"marker: {n\nentry: {n}",
+		"marker: {n}\nentry: {n}\norder: random",
+		"base: harvard",
+		"marker: <{n}>\nbase: apa",
+		"colour: blue",
+	} {
+		if _, err := ParseStyle(strings.NewReader(src)); err == nil {
+			t.Errorf("Expected an error for %q", src)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package cite
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A template is literal text with {field} placeholders. A placeholder may
+// carry a prefix and a suffix, {field|prefix|suffix}, which are only written
+// when the field is not empty. "{{" and "}}" stand for literal braces.
+type template []segment
+
+type segment struct {
+	literal string
+	field   string
+	prefix  string
+	suffix  string
+}
+
+func parseTemplate(src string) (template, error) {
+	var t template
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			t = append(t, segment{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(src); i++ {
+		switch {
+		case strings.HasPrefix(src[i:], "{{"), strings.HasPrefix(src[i:], "}}"):
+			literal.WriteByte(src[i])
+			i++
+		case src[i] == '{':
+			end := strings.IndexByte(src[i:], '}')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated placeholder in %q", src)
+			}
+			parts := strings.SplitN(src[i+1:i+end], "|", 3)
+			if !knownField(parts[0]) {
+				return nil, fmt.Errorf("unknown field {%s}", parts[0])
+			}
+			seg := segment{field: parts[0]}
+			if len(parts) > 1 {
+				seg.prefix = parts[1]
+			}
+			if len(parts) > 2 {
+				seg.suffix = parts[2]
+			}
+			flush()
+			t = append(t, seg)
+			i += end
+		case src[i] == '}':
+			return nil, fmt.Errorf("unexpected } in %q", src)
+		default:
+			literal.WriteByte(src[i])
+		}
+	}
+	flush()
+
+	return t, nil
+}
+
+// execute expands the template. value looks fields up and wrap decorates
+// their expansion, for example to turn a URL into a link.
+func (t template) execute(value func(field string) string, wrap func(field, value string) string) string {
+	var b strings.Builder
+	for _, seg := range t {
+		if seg.field == "" {
+			b.WriteString(seg.literal)
+			continue
+		}
+		v := value(seg.field)
+		if v == "" {
+			continue
+		}
+		b.WriteString(seg.prefix)
+		b.WriteString(wrap(seg.field, v))
+		b.WriteString(seg.suffix)
+	}
+	return b.String()
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package diag describes problems found while reading nanami documents and
+// webographies.
+package diag
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+)
+
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic is a single problem tied to a location in a file. Line and
+// Column start at 1, with a zero Line meaning the whole file; File may be
+// empty when the caller prints it separately.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Severity Severity
+	Code     string
+	Message  string
+}
+
+// Error formats the diagnostic as "file:line:col: message [code]", with a
+// "warning: " prefix on the message for warnings. Missing parts of the
+// location are left out.
+func (d Diagnostic) Error() string {
+	var b strings.Builder
+	if d.File != "" {
+		b.WriteString(d.File)
+		b.WriteByte(':')
+	}
+	if d.Line > 0 {
+		fmt.Fprintf(&b, "%d:%d:", d.Line, d.Column)
+	}
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	if d.Severity == Warning {
+		b.WriteString("warning: ")
+	}
+	b.WriteString(d.Message)
+	if d.Code != "" {
+		fmt.Fprintf(&b, " [%s]", d.Code)
+	}
+	return b.String()
+}
+
+// List collects diagnostics in the order they were reported. A non-empty
+// List is itself an error whose Unwrap exposes each diagnostic.
+type List []Diagnostic
+
+func (l *List) Errorf(line, column int, code, format string, args ...any) {
+	l.add(Error, line, column, code, format, args...)
+}
+
+func (l *List) Warnf(line, column int, code, format string, args ...any) {
+	l.add(Warning, line, column, code, format, args...)
+}
+
+func (l *List) add(sev Severity, line, column int, code, format string, args ...any) {
+	*l = append(*l, Diagnostic{
+		Line:     line,
+		Column:   column,
+		Severity: sev,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// HasErrors reports whether any diagnostic in the list is an error rather
+// than a warning.
+func (l List) HasErrors() bool {
+	for _, d := range l {
+		if d.Severity == Error {
+			return true
+		}
+	}
+	return false
+}
+
+// WithFile returns a copy of the list with File set on every diagnostic that
+// does not already name one.
+func (l List) WithFile(file string) List {
+	out := make(List, len(l))
+	for i, d := range l {
+		if d.File == "" {
+			d.File = file
+		}
+		out[i] = d
+	}
+	return out
+}
+
+// Err returns the list as an error, or nil when it is empty.
+func (l List) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+func (l List) Error() string {
+	msgs := make([]string, len(l))
+	for i, d := range l {
+		msgs[i] = d.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (l List) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, d := range l {
+		errs[i] = d
+	}
+	return errs
+}
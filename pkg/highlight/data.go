@@ -0,0 +1,127 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package highlight
+
+import "strings"
+
+// followedByColon reports whether the next non-blank byte after end on the
+// same line is a colon, which makes a string a key.
+func (s *scanner) followedByColon(end int) bool {
+	i := s.while(end, func(c byte) bool { return c == ' ' || c == '\t' })
+	return i < len(s.src) && s.src[i] == ':'
+}
+
+func json(src string) []Token {
+	s := &scanner{src: src}
+	for !s.done() {
+		c := s.src[s.pos]
+		switch {
+		case c == '"':
+			end := s.quoted(true, false)
+			class := String
+			if s.followedByColon(end) {
+				class = Key
+			}
+			s.emit(class, end)
+		case c == '-' || isDigit(c):
+			s.emit(Number, s.while(s.pos+1, func(c byte) bool {
+				return isDigit(c) || strings.IndexByte(".eE+-", c) >= 0
+			}))
+		case isLetter(c):
+			end := s.while(s.pos, isWord)
+			class := Plain
+			switch s.src[s.pos:end] {
+			case "true", "false", "null":
+				class = Literal
+			}
+			s.emit(class, end)
+		default:
+			s.emit(Plain, s.pos+1)
+		}
+	}
+	return s.tokens
+}
+
+var yamlLiterals = map[string]bool{
+	"true": true, "false": true, "null": true, "~": true,
+	"True": true, "False": true, "Null": true, "TRUE": true, "FALSE": true, "NULL": true,
+	"yes": true, "no": true, "on": true, "off": true,
+}
+
+func yaml(src string) []Token {
+	s := &scanner{src: src}
+	for !s.done() {
+		c := s.src[s.pos]
+		switch {
+		case c == '#' && s.afterBlank():
+			s.emit(Comment, s.lineEnd())
+		case s.atLineStart() && (strings.HasPrefix(s.rest(), "---") || strings.HasPrefix(s.rest(), "...")):
+			s.emit(Keyword, s.pos+3)
+		case c == '"' || c == '\'':
+			end := s.quoted(c == '"', false)
+			class := String
+			if s.followedByColon(end) {
+				class = Key
+			}
+			s.emit(class, end)
+		case (c == '&' || c == '*') && s.afterBlank():
+			s.emit(Variable, s.while(s.pos+1, func(c byte) bool { return !isSpace(c) }))
+		case isSpace(c) || strings.IndexByte("-:,[]{}?|>", c) >= 0:
+			s.emit(Plain, s.pos+1)
+		default:
+			s.yamlScalar()
+		}
+	}
+	return s.tokens
+}
+
+// yamlScalar emits the plain scalar at the current position: a mapping key
+// when a colon follows it, else a literal, a number or plain text.
+func (s *scanner) yamlScalar() {
+	end := s.pos
+	for end < len(s.src) {
+		c := s.src[end]
+		if c == '\n' || c == ',' && s.inFlow() || c == ':' && (end+1 == len(s.src) || isSpace(s.src[end+1])) ||
+			c == '#' && isSpace(s.src[end-1]) {
+			break
+		}
+		end++
+	}
+	value := strings.TrimRight(s.src[s.pos:end], " \t")
+	end = s.pos + len(value)
+
+	switch {
+	case end < len(s.src) && s.src[end] == ':':
+		s.emit(Key, end)
+	case yamlLiterals[value]:
+		s.emit(Literal, end)
+	case isNumber(value):
+		s.emit(Number, end)
+	default:
+		s.emit(Plain, end)
+	}
+}
+
+// inFlow reports whether the current position is inside a [...] or {...}
+// collection on its line.
+func (s *scanner) inFlow() bool {
+	start := strings.LastIndexByte(s.src[:s.pos], '\n') + 1
+	line := s.src[start:s.pos]
+	return strings.Count(line, "[")+strings.Count(line, "{") > strings.Count(line, "]")+strings.Count(line, "}")
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package highlight
+
+import "strings"
+
+var goWords = map[string]Class{}
+
+func init() {
+	for _, w := range strings.Fields(`break case chan const continue default defer else fallthrough
+		for func go goto if import interface map package range return select struct switch type var`) {
+		goWords[w] = Keyword
+	}
+	for _, w := range strings.Fields(`any bool byte comparable complex64 complex128 error float32 float64
+		int int8 int16 int32 int64 rune string uint uint8 uint16 uint32 uint64 uintptr
+		append cap clear close complex copy delete imag len make max min new panic print println real recover`) {
+		goWords[w] = Builtin
+	}
+	for _, w := range []string{"true", "false", "nil", "iota"} {
+		goWords[w] = Literal
+	}
+}
+
+func golang(src string) []Token {
+	s := &scanner{src: src}
+	for !s.done() {
+		c := s.src[s.pos]
+		switch {
+		case strings.HasPrefix(s.rest(), "//"):
+			s.emit(Comment, s.lineEnd())
+		case strings.HasPrefix(s.rest(), "/*"):
+			end := len(s.src)
+			if i := strings.Index(s.rest()[2:], "*/"); i >= 0 {
+				end = s.pos + 2 + i + 2
+			}
+			s.emit(Comment, end)
+		case c == '"' || c == '\'':
+			s.emit(String, s.quoted(true, false))
+		case c == '`':
+			s.emit(String, s.quoted(false, true))
+		case isDigit(c):
+			s.emit(Number, s.while(s.pos, func(c byte) bool { return isWord(c) || c == '.' }))
+		case isLetter(c):
+			end := s.while(s.pos, isWord)
+			s.emit(goWords[s.src[s.pos:end]], end)
+		default:
+			s.emit(Plain, s.pos+1)
+		}
+	}
+	return s.tokens
+}
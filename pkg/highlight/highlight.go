@@ -0,0 +1,178 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package highlight splits source code into classified tokens for syntax
+// highlighting. It knows Go, shell, JSON, YAML and nanami itself.
+package highlight
+
+import "strings"
+
+// Class says what a token is. The empty class is plain text.
+type Class string
+
+const (
+	Plain    Class = ""
+	Keyword  Class = "keyword"
+	String   Class = "string"
+	Comment  Class = "comment"
+	Number   Class = "number"
+	Literal  Class = "literal"  // true, false, nil and the like
+	Builtin  Class = "builtin"  // predeclared types, functions and commands
+	Key      Class = "key"      // keys of JSON objects and YAML mappings
+	Variable Class = "variable" // shell variables, YAML anchors, citations
+)
+
+// Token is a piece of source code and its class.
+type Token struct {
+	Class Class
+	Text  string
+}
+
+var languages = map[string]func(src string) []Token{
+	"go":     golang,
+	"golang": golang,
+	"sh":     shell,
+	"shell":  shell,
+	"bash":   shell,
+	"json":   json,
+	"yaml":   yaml,
+	"yml":    yaml,
+	"nanami": nanami,
+	"nama":   nanami,
+}
+
+// Supported reports whether Tokens knows the language named lang.
+func Supported(lang string) bool {
+	_, ok := languages[strings.ToLower(lang)]
+	return ok
+}
+
+// Tokens splits src, written in lang, into tokens whose texts add up to src.
+// Code in an unknown language comes back as a single plain token.
+func Tokens(lang, src string) []Token {
+	if src == "" {
+		return nil
+	}
+	if tokenize, ok := languages[strings.ToLower(lang)]; ok {
+		return tokenize(src)
+	}
+	return []Token{{Plain, src}}
+}
+
+// scanner walks source code, emitting tokens as it goes.
+type scanner struct {
+	src    string
+	pos    int
+	tokens []Token
+}
+
+func (s *scanner) done() bool {
+	return s.pos >= len(s.src)
+}
+
+func (s *scanner) rest() string {
+	return s.src[s.pos:]
+}
+
+// emit turns the source up to end into a token of class, merging it with
+// the previous token when that has the same class.
+func (s *scanner) emit(class Class, end int) {
+	if end <= s.pos {
+		end = s.pos + 1
+	}
+	if end > len(s.src) {
+		end = len(s.src)
+	}
+	text := s.src[s.pos:end]
+	s.pos = end
+
+	if n := len(s.tokens); n > 0 && s.tokens[n-1].Class == class {
+		s.tokens[n-1].Text += text
+		return
+	}
+	s.tokens = append(s.tokens, Token{class, text})
+}
+
+// while returns the index of the first byte from start on that accept
+// rejects.
+func (s *scanner) while(start int, accept func(byte) bool) int {
+	for start < len(s.src) && accept(s.src[start]) {
+		start++
+	}
+	return start
+}
+
+// lineEnd returns the index of the end of the current line.
+func (s *scanner) lineEnd() int {
+	if i := strings.IndexByte(s.rest(), '\n'); i >= 0 {
+		return s.pos + i
+	}
+	return len(s.src)
+}
+
+// quoted returns the index just past the string starting at the current
+// position, which opens with quote. Backslashes escape the next byte when
+// escapes is set; strings that may not span lines end with the line.
+func (s *scanner) quoted(escapes, multiline bool) int {
+	quote := s.src[s.pos]
+	for i := s.pos + 1; i < len(s.src); i++ {
+		switch c := s.src[i]; {
+		case escapes && c == '\\':
+			i++
+		case c == quote:
+			return i + 1
+		case c == '\n' && !multiline:
+			return i
+		}
+	}
+	return len(s.src)
+}
+
+// atLineStart reports whether only blanks precede the current position on
+// its line.
+func (s *scanner) atLineStart() bool {
+	start := strings.LastIndexByte(s.src[:s.pos], '\n') + 1
+	return strings.TrimLeft(s.src[start:s.pos], " \t") == ""
+}
+
+// afterBlank reports whether the current position starts the source or
+// follows a blank.
+func (s *scanner) afterBlank() bool {
+	return s.pos == 0 || isSpace(s.src[s.pos-1])
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c >= 0x80
+}
+
+func isWord(c byte) bool {
+	return isLetter(c) || isDigit(c)
+}
+
+func isNumber(word string) bool {
+	word = strings.TrimLeft(word, "+-")
+	return word != "" && isDigit(word[0]) && strings.Trim(word, "0123456789._xXoObBeEaAcCdDfF+-") == ""
+}
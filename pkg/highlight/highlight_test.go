@@ -0,0 +1,74 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package highlight
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// classes renders tokens as text with the non-plain ones marked up, e.g.
+// "<keyword:func> main".
+func classes(tokens []Token) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		if t.Class == Plain {
+			b.WriteString(t.Text)
+		} else {
+			fmt.Fprintf(&b, "<%s:%s>", t.Class, t.Text)
+		}
+	}
+	return b.String()
+}
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		lang, src, want string
+	}{
+		{"go", "func f() int { return len(`a`) + 0x1F // done\n}",
+			"<keyword:func> f() <builtin:int> { <keyword:return> <builtin:len>(<string:`a`>) + <number:0x1F> <comment:// done>\n}"},
+		{"go", `x := "a\"b" /* c */ != nil`,
+			`x := <string:"a\"b"> <comment:/* c */> != <literal:nil>`},
+		{"sh", "if [ \"$1\" ]; then echo ${HOME} 'a#b' # note\nfi",
+			"<keyword:if> [ <string:\"$1\"> ]; <keyword:then> <builtin:echo> <variable:${HOME}> <string:'a#b'> <comment:# note>\n<keyword:fi>"},
+		{"json", `{"a": [1.5, -2e3, "x"], "b": null}`,
+			`{<key:"a">: [<number:1.5>, <number:-2e3>, <string:"x">], <key:"b">: <literal:null>}`},
+		{"yaml", "---\nname: nanami # tool\nitems:\n  - on: yes\n    n: 12\n    ref: *base\n",
+			"<keyword:--->\n<key:name>: nanami <comment:# tool>\n<key:items>:\n  - <key:on>: <literal:yes>\n    <key:n>: <number:12>\n    <key:ref>: <variable:*base>\n"},
+		{"nanami", "title: x\n!nlp\ncase(a) {\n\ttext { see ${k, p. 2} {https://x.example}{here} {*case} }\n}\nT: k",
+			"<keyword:title>: x\n<keyword:!nlp>\n<keyword:case>(a) {\n\t<keyword:text> { see <variable:${k, p. 2}> <string:{https://x.example}>{here} {*case} }\n}\n<key:T:> k"},
+		{"cobol", "MOVE A TO B", "MOVE A TO B"},
+	}
+
+	for _, test := range tests {
+		tokens := Tokens(test.lang, test.src)
+		if got := classes(tokens); got != test.want {
+			t.Errorf("%s: expected\n%s\ngot\n%s", test.lang, test.want, got)
+		}
+
+		var joined strings.Builder
+		for _, tok := range tokens {
+			joined.WriteString(tok.Text)
+		}
+		if joined.String() != test.src {
+			t.Errorf("%s: tokens do not add up to the source: %q", test.lang, joined.String())
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package highlight
+
+import "strings"
+
+// nanamiBlocks are the words that open headers and blocks in a document.
+var nanamiBlocks = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`title style content case text sources html code figure quote note warning aside list olist table webography references`) {
+		nanamiBlocks[w] = true
+	}
+}
+
+func nanami(src string) []Token {
+	s := &scanner{src: src}
+	for !s.done() {
+		c := s.src[s.pos]
+		switch {
+		case c == '!' && s.atLineStart():
+			s.emit(Keyword, s.while(s.pos+1, isWord))
+		case strings.HasPrefix(s.rest(), "${"):
+			s.emit(Variable, s.braceGroup(s.pos+1))
+		case c == '{' && nanamiGroupClass(s.rest()) != Plain:
+			s.emit(nanamiGroupClass(s.rest()), s.braceGroup(s.pos))
+		case isLetter(c):
+			end := s.while(s.pos, func(c byte) bool { return isWord(c) || c == '-' })
+			word := s.src[s.pos:end]
+			class := Plain
+			switch {
+			case nanamiBlocks[word] && s.opensBlock(end):
+				class = Keyword
+			case s.atLineStart() && len(word) <= 2 && strings.ToUpper(word) == word &&
+				end < len(s.src) && s.src[end] == ':':
+				// A webography field such as T: or AD:.
+				class, end = Key, end+1
+			}
+			s.emit(class, end)
+		default:
+			s.emit(Plain, s.pos+1)
+		}
+	}
+	return s.tokens
+}
+
+// opensBlock reports whether a word ending at end is followed by (, { or :
+// as block and header names are.
+func (s *scanner) opensBlock(end int) bool {
+	i := s.while(end, func(c byte) bool { return c == ' ' || c == '\t' })
+	return i < len(s.src) && strings.IndexByte("({:", s.src[i]) >= 0
+}
+
+// braceGroup returns the index just past the brace group opening at start,
+// or just past the brace when it is not closed on its line.
+func (s *scanner) braceGroup(start int) int {
+	depth := 0
+	for i := start; i < len(s.src) && s.src[i] != '\n'; i++ {
+		switch s.src[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return start + 1
+}
+
+// nanamiGroupClass classifies an inline brace group: links and images are
+// strings and footnotes placeholders built in. Other groups are plain, and
+// their content is highlighted on its own.
+func nanamiGroupClass(group string) Class {
+	switch {
+	case strings.HasPrefix(group, "{http://"), strings.HasPrefix(group, "{https://"),
+		strings.HasPrefix(group, "{img/"):
+		return String
+	case strings.HasPrefix(group, "{footnotes"):
+		return Builtin
+	}
+	return Plain
+}
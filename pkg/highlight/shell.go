@@ -0,0 +1,87 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package highlight
+
+import "strings"
+
+var shellWords = map[string]Class{}
+
+func init() {
+	for _, w := range strings.Fields(`if then else elif fi for while until do done case esac in
+		function return select break continue`) {
+		shellWords[w] = Keyword
+	}
+	for _, w := range strings.Fields(`cd echo eval exec exit export local printf read readonly set
+		shift source test trap unset`) {
+		shellWords[w] = Builtin
+	}
+}
+
+func isShellWord(c byte) bool {
+	return !isSpace(c) && !strings.ContainsRune(`;&|()<>'"$#`+"`", rune(c))
+}
+
+func shell(src string) []Token {
+	s := &scanner{src: src}
+	for !s.done() {
+		c := s.src[s.pos]
+		switch {
+		case c == '#' && s.afterBlank():
+			s.emit(Comment, s.lineEnd())
+		case c == '\'':
+			s.emit(String, s.quoted(false, true))
+		case c == '"':
+			s.emit(String, s.quoted(true, true))
+		case c == '$':
+			s.emit(Variable, s.variable())
+		case isShellWord(c):
+			end := s.while(s.pos, isShellWord)
+			word := s.src[s.pos:end]
+			class := shellWords[word]
+			if isNumber(word) {
+				class = Number
+			}
+			s.emit(class, end)
+		default:
+			s.emit(Plain, s.pos+1)
+		}
+	}
+	return s.tokens
+}
+
+// variable returns the end of the shell variable at the current position:
+// ${name}, $name or a special parameter such as $1 or $?. A $ that starts
+// none of them, as in $(cmd), stands alone.
+func (s *scanner) variable() int {
+	next := s.pos + 1
+	switch {
+	case next >= len(s.src):
+		return next
+	case s.src[next] == '{':
+		if i := strings.IndexByte(s.src[next:], '}'); i >= 0 {
+			return next + i + 1
+		}
+		return next
+	case isLetter(s.src[next]):
+		return s.while(next, isWord)
+	case isDigit(s.src[next]) || strings.IndexByte("@*#?$!-", s.src[next]) >= 0:
+		return next + 1
+	}
+	return next
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package links
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Cache remembers link statuses between runs in a JSON file.
+type Cache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]Status
+}
+
+// DefaultCachePath returns the cache file used when none is given: a
+// links.json in the nanami directory of the user's cache directory.
+func DefaultCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "nanami", "links.json"), nil
+}
+
+// OpenCache loads the cache stored at path. A missing file gives an empty
+// cache that Save will create.
+func OpenCache(path string) (*Cache, error) {
+	c := &Cache{path: path, entries: map[string]Status{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil, err
+	}
+	for _, status := range statuses {
+		c.entries[status.URL] = status
+	}
+	return c, nil
+}
+
+// Get returns the cached status of url.
+func (c *Cache) Get(url string) (Status, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status, ok := c.entries[url]
+	return status, ok
+}
+
+// Put records the status of a URL, replacing any older one.
+func (c *Cache) Put(status Status) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[status.URL] = status
+}
+
+// Save writes the cache back to its file, creating its directory if
+// needed.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	statuses := make([]Status, 0, len(c.entries))
+	for _, status := range c.entries {
+		statuses = append(statuses, status)
+	}
+	c.mu.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].URL < statuses[j].URL
+	})
+	data, err := json.MarshalIndent(statuses, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+// Package links checks that the URLs a document and its webography point at
+// still resolve.
+package links
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Client sends HTTP requests. *http.Client satisfies it; tests can use the
+// client of an httptest server.
+type Client interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Status is the outcome of checking one URL.
+type Status struct {
+	URL string `json:"url"`
+	// Code is the HTTP status code, or 0 when no response arrived.
+	Code int `json:"code,omitempty"`
+	// Location is where the URL redirects to, if anywhere.
+	Location string `json:"location,omitempty"`
+	// Err describes why no response arrived.
+	Err     string    `json:"error,omitempty"`
+	Checked time.Time `json:"checked"`
+}
+
+// Dead reports whether the URL could not be fetched or answered with an
+// error status.
+func (s Status) Dead() bool {
+	return s.Err != "" || s.Code >= 400
+}
+
+// Redirected reports whether the URL points somewhere else.
+func (s Status) Redirected() bool {
+	return s.Location != ""
+}
+
+// Checker checks URLs with Client, consulting Cache first when it is set.
+type Checker struct {
+	Client Client
+	Cache  *Cache
+	// MaxAge is how long a cached status stays valid. Zero ignores the
+	// cache when reading but still records fresh results in it.
+	MaxAge time.Duration
+
+	now func() time.Time
+}
+
+// NewChecker returns a checker using client, or a default client that
+// gives up after timeout and reports redirects instead of following them
+// when client is nil.
+func NewChecker(client Client, timeout time.Duration) *Checker {
+	if client == nil {
+		client = &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return &Checker{Client: client, now: time.Now}
+}
+
+// Check returns the status of url, from the cache when a fresh enough
+// result is there.
+func (c *Checker) Check(ctx context.Context, url string) Status {
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+
+	if c.Cache != nil && c.MaxAge > 0 {
+		if status, ok := c.Cache.Get(url); ok && now().Sub(status.Checked) < c.MaxAge {
+			return status
+		}
+	}
+
+	status := c.fetch(ctx, url)
+	status.Checked = now()
+	if c.Cache != nil {
+		c.Cache.Put(status)
+	}
+	return status
+}
+
+// fetch asks for the headers of url, falling back to a GET for servers
+// that do not support HEAD.
+func (c *Checker) fetch(ctx context.Context, url string) Status {
+	status := Status{URL: url}
+
+	var resp *http.Response
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequestWithContext(ctx, method, url, nil)
+		if err != nil {
+			status.Err = err.Error()
+			return status
+		}
+		if resp, err = c.Client.Do(req); err != nil {
+			status.Err = err.Error()
+			return status
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+			break
+		}
+	}
+
+	status.Code = resp.StatusCode
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		if location, err := resp.Location(); err == nil {
+			status.Location = location.String()
+		}
+	case resp.Request != nil && resp.Request.URL.String() != url:
+		// The client followed the redirects itself.
+		status.Location = resp.Request.URL.String()
+	}
+	return status
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package links
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+	"github.com/nanomarkdown/nanami/pkg/diag"
+)
+
+// Diagnostic codes reported by Document.
+const (
+	CodeDeadLink       = "dead-link"
+	CodeRedirect       = "redirect"
+	CodeMissingArchive = "missing-archive"
+)
+
+// Document checks the {https://...} links in doc and the L: and AR: URLs
+// of its webography entries. Dead links are errors; redirects and entries
+// without an archived copy are warnings. Each URL is fetched once however
+// often it appears. Like check.Document, diagnostics about the document
+// carry no file name and those about entries name their webography file.
+func Document(ctx context.Context, doc *ast.Document, checker *Checker) diag.List {
+	var diags diag.List
+	statuses := map[string]Status{}
+	check := func(url string) Status {
+		status, ok := statuses[url]
+		if !ok {
+			status = checker.Check(ctx, url)
+			statuses[url] = status
+		}
+		return status
+	}
+
+	walk(doc, func(n ast.Node) {
+		ast.WalkInline(ast.InlineContent(n), func(in ast.Inline) {
+			if link, ok := in.(*ast.Link); ok {
+				report(&diags, n.Start().Line, n.Start().Column, "link", link.URL, check(link.URL))
+			}
+		})
+	})
+
+	bib := doc.Webography
+	if bib == nil {
+		return diags
+	}
+	for _, entry := range bib.Entries() {
+		if entry.URL == "" {
+			continue
+		}
+
+		origin, _ := bib.Origin(entry.Keyword)
+		line, column := origin.Line, 0
+		if line > 0 {
+			column = 1
+		}
+
+		var entryDiags diag.List
+		report(&entryDiags, line, column, fmt.Sprintf("entry %q URL", entry.Keyword), entry.URL,
+			check(entry.URL))
+		if entry.ArchiveURL == "" {
+			entryDiags.Warnf(line, column, CodeMissingArchive,
+				"entry %q has no archived copy (AR:)", entry.Keyword)
+		} else {
+			report(&entryDiags, line, column, fmt.Sprintf("entry %q archive", entry.Keyword), entry.ArchiveURL,
+				check(entry.ArchiveURL))
+		}
+		diags = append(diags, entryDiags.WithFile(origin.File)...)
+	}
+	return diags
+}
+
+func report(diags *diag.List, line, column int, what, url string, status Status) {
+	switch {
+	case status.Err != "":
+		diags.Errorf(line, column, CodeDeadLink, "%s %s is unreachable: %s", what, url, status.Err)
+	case status.Dead():
+		diags.Errorf(line, column, CodeDeadLink, "%s %s is dead: HTTP %d", what, url, status.Code)
+	case status.Redirected():
+		diags.Warnf(line, column, CodeRedirect, "%s %s redirects to %s", what, url, status.Location)
+	}
+}
+
+// walk calls visit for n and every node below it.
+func walk(n ast.Node, visit func(n ast.Node)) {
+	visit(n)
+	for _, child := range n.Children() {
+		walk(child, visit)
+	}
+}
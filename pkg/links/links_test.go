@@ -0,0 +1,170 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package links
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+	"github.com/nanomarkdown/nanami/pkg/parser"
+)
+
+func newServer(t *testing.T) (*httptest.Server, *int) {
+	hits := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/get-only", func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		http.NotFound(w, r)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestCheck(t *testing.T) {
+	server, _ := newServer(t)
+	checker := NewChecker(nil, time.Second)
+
+	tests := []struct {
+		path       string
+		dead       bool
+		redirected bool
+	}{
+		{"/ok", false, false},
+		{"/moved", false, true},
+		{"/get-only", false, false},
+		{"/gone", true, false},
+	}
+	for _, test := range tests {
+		status := checker.Check(context.Background(), server.URL+test.path)
+		if status.Dead() != test.dead || status.Redirected() != test.redirected {
+			t.Errorf("%s: expected dead=%v redirected=%v, got %+v", test.path, test.dead, test.redirected, status)
+		}
+	}
+
+	if status := checker.Check(context.Background(), "http://127.0.0.1:1/"); !status.Dead() || status.Err == "" {
+		t.Errorf("Expected an unreachable server to be dead, got %+v", status)
+	}
+}
+
+func TestCheckFollowingClient(t *testing.T) {
+	server, _ := newServer(t)
+	status := NewChecker(server.Client(), 0).Check(context.Background(), server.URL+"/moved")
+	if status.Code != http.StatusOK || status.Location != server.URL+"/ok" {
+		t.Errorf("Expected the redirect target to be recorded, got %+v", status)
+	}
+}
+
+func TestCheckUsesCache(t *testing.T) {
+	server, hits := newServer(t)
+	path := filepath.Join(t.TempDir(), "cache", "links.json")
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	check := func() Status {
+		cache, err := OpenCache(path)
+		if err != nil {
+			t.Fatalf("OpenCache returned %v", err)
+		}
+		checker := NewChecker(nil, time.Second)
+		checker.Cache = cache
+		checker.MaxAge = time.Hour
+		checker.now = func() time.Time { return now }
+
+		status := checker.Check(context.Background(), server.URL+"/gone")
+		if err := cache.Save(); err != nil {
+			t.Fatalf("Save returned %v", err)
+		}
+		return status
+	}
+
+	check()
+	now = now.Add(30 * time.Minute)
+	if status := check(); status.Code != http.StatusNotFound || *hits != 1 {
+		t.Errorf("Expected the cached 404 without a new request, got %+v after %d requests", status, *hits)
+	}
+	now = now.Add(time.Hour)
+	if check(); *hits != 2 {
+		t.Errorf("Expected a stale entry to be checked again, got %d requests", *hits)
+	}
+}
+
+func TestDocument(t *testing.T) {
+	server, hits := newServer(t)
+
+	bib := ast.NewWebography()
+	err := bib.Load(strings.NewReader(strings.ReplaceAll(`T: ok
+L: SERVER/ok
+AR: SERVER/archived
+
+T: moved
+L: SERVER/moved
+AR: SERVER/ok
+
+T: offline
+N: No URL
+`, "SERVER", server.URL)))
+	if err != nil {
+		t.Fatalf("Load returned %v", err)
+	}
+
+	doc := &ast.Document{
+		Content: []ast.Node{
+			&ast.TextNode{
+				Span:       ast.Span{From: ast.Position{Line: 2, Column: 1}},
+				Paragraphs: parser.ParseParagraphs("see {" + server.URL + "/gone}{here} and {" + server.URL + "/ok}"),
+			},
+		},
+		Webography: bib,
+	}
+
+	var got []string
+	for _, d := range Document(context.Background(), doc, NewChecker(nil, time.Second)) {
+		got = append(got, strings.ReplaceAll(d.Error(), server.URL, "SERVER"))
+	}
+	want := []string{
+		`2:1: link SERVER/gone is dead: HTTP 404 [dead-link]`,
+		`1:1: entry "ok" archive SERVER/archived is dead: HTTP 404 [dead-link]`,
+		`5:1: warning: entry "moved" URL SERVER/moved redirects to SERVER/ok [redirect]`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected diagnostics:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+	if *hits != 4 {
+		t.Errorf("Expected each URL to be fetched once, got %d requests", *hits)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package parser
+
+import "github.com/nanomarkdown/nanami/pkg/ast"
+
+// Diagnostic codes reported by the parser.
+const (
+	CodeUnknownDirective  = "unknown-directive"
+	CodeUnterminatedBlock = "unterminated-block"
+	CodeMalformedCase     = "malformed-case"
+	CodeStrayBrace        = "stray-brace"
+	CodeMissingBrace      = "missing-brace"
+	CodeUnreadableFile    = "unreadable-file"
+	CodeBadTable          = "bad-table"
+	CodeBadFigure         = "bad-figure"
+	CodeCodeIndent        = "code-indent"
+)
+
+func (p *parser) errorf(pos ast.Position, code, format string, args ...any) {
+	p.diags.Errorf(pos.Line, pos.Column, code, format, args...)
+}
+
+func (p *parser) warnf(pos ast.Position, code, format string, args ...any) {
+	p.diags.Warnf(pos.Line, pos.Column, code, format, args...)
+}
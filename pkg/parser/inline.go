@@ -0,0 +1,189 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package parser
+
+import (
+	"strings"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+	stringUtil "github.com/nanomarkdown/nanami/pkg/common/strings"
+)
+
+// inlineMarkup maps the openers of brace markup to the styles they apply.
+// {** comes before {* so that strong text is not read as emphasis.
+var inlineMarkup = []struct {
+	opener string
+	style  ast.InlineStyle
+}{
+	{"{**", ast.Strong},
+	{"{*", ast.Emphasis},
+	{"{~", ast.Strike},
+	{"{_", ast.Subscript},
+	{"{^", ast.Superscript},
+	{"{=", ast.Highlight},
+}
+
+// ParseParagraphs parses the content of a text block, as laid out in
+// TextNode.Content, into paragraphs of inline elements.
+func ParseParagraphs(content string) []ast.Paragraph {
+	if content == "" {
+		return nil
+	}
+	var paragraphs []ast.Paragraph
+	for _, paragraph := range strings.Split(content, "\n\n") {
+		paragraphs = append(paragraphs, ParseInline(paragraph))
+	}
+	return paragraphs
+}
+
+// footnotesPlaceholders maps the {footnotes} placeholders to their scopes.
+var footnotesPlaceholders = map[string]ast.FootnotesScope{
+	"{footnotes}":      ast.FootnotesCurrent,
+	"{footnotes:case}": ast.FootnotesCase,
+	"{footnotes:all}":  ast.FootnotesAll,
+}
+
+// ParseInline parses inline content: newlines become hard line breaks, and
+// ${...} citations, {https://...} links, {img/...} images, {footnotes}
+// placeholders, {html:...} raw HTML, {ref:id} figure references and brace
+// markup such as {*text} or {`code} become the matching elements. Anything
+// else, including unbalanced braces, is kept as text.
+func ParseInline(s string) []ast.Inline {
+	var nodes []ast.Inline
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, &ast.Text{Value: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '\n':
+			flush()
+			nodes = append(nodes, &ast.LineBreak{})
+			i++
+		case '$', '{':
+			node, next := parseInlineElement(s, i)
+			if node == nil {
+				text.WriteByte(s[i])
+				i++
+				break
+			}
+			flush()
+			nodes = append(nodes, node)
+			i = next
+		default:
+			text.WriteByte(s[i])
+			i++
+		}
+	}
+	flush()
+
+	return nodes
+}
+
+// parseInlineElement parses the element starting at s[start], returning it
+// and the index just past it, or nil if s[start] starts no element.
+func parseInlineElement(s string, start int) (ast.Inline, int) {
+	if s[start] == '$' {
+		if start+1 >= len(s) || s[start+1] != '{' {
+			return nil, start
+		}
+		end := stringUtil.FindClosingBrace(s, start+2)
+		if end == -1 {
+			return nil, start
+		}
+		citation := ast.ParseCitation(s[start+2 : end])
+		return &citation, end + 1
+	}
+
+	end := stringUtil.FindClosingBrace(s, start+1)
+	if end == -1 {
+		return nil, start
+	}
+	group := s[start : end+1]
+
+	// Links and images may take their text from a second brace group.
+	second := func() (string, int, bool) {
+		if end+1 < len(s) && s[end+1] == '{' {
+			if textEnd := stringUtil.FindClosingBrace(s, end+2); textEnd != -1 {
+				return s[end+2 : textEnd], textEnd + 1, true
+			}
+		}
+		return "", end + 1, false
+	}
+
+	switch {
+	case strings.HasPrefix(group, "{img/"):
+		alt, next, ok := second()
+		if !ok {
+			return nil, start
+		}
+		return &ast.Image{Src: group[5 : len(group)-1], Alt: alt}, next
+	case stringUtil.StartsWithHttp(s, start+1):
+		link := &ast.Link{URL: group[1 : len(group)-1]}
+		linkText, next, ok := second()
+		if ok {
+			link.Children = ParseInline(linkText)
+		}
+		return link, next
+	}
+
+	if raw, ok := strings.CutPrefix(group, "{html:"); ok {
+		return &ast.RawHTML{Value: raw[:len(raw)-1]}, end + 1
+	}
+	if id, ok := strings.CutPrefix(group, "{ref:"); ok {
+		if id = strings.TrimSpace(id[:len(id)-1]); id != "" {
+			return &ast.Ref{ID: id}, end + 1
+		}
+		return nil, start
+	}
+	if scope, ok := footnotesPlaceholders[group]; ok {
+		return &ast.Footnotes{Scope: scope}, end + 1
+	}
+	if node := parseMarkup(group); node != nil {
+		return node, end + 1
+	}
+	return nil, start
+}
+
+// parseMarkup parses a brace group holding inline markup. It returns nil
+// for groups that are not markup or have nothing inside.
+func parseMarkup(group string) ast.Inline {
+	if strings.HasPrefix(group, "{`") {
+		if code := group[2 : len(group)-1]; code != "" {
+			return &ast.Code{Value: code}
+		}
+		return nil
+	}
+
+	for _, markup := range inlineMarkup {
+		if !strings.HasPrefix(group, markup.opener) {
+			continue
+		}
+		inner := group[len(markup.opener) : len(group)-1]
+		if strings.TrimSpace(inner) == "" {
+			return nil
+		}
+		return &ast.Styled{Style: markup.style, Children: ParseInline(inner)}
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package parser
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+)
+
+type tokenKind int
+
+const (
+	tokEOF       tokenKind = iota
+	tokIdent               // title, content, case, text, ...
+	tokDirective           // !nlp
+	tokColon
+	tokLParen
+	tokLBrace
+	tokRBrace
+	tokOther // any other run of non-blank characters
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  ast.Position
+}
+
+// lexer splits a document into tokens as it is read. Block bodies and
+// parenthesised arguments are not tokenised: the parser asks for them raw
+// with blockBody and parenArg once it knows where they start.
+type lexer struct {
+	r   *bufio.Reader
+	pos ast.Position // position of the next rune
+	err error        // first read error other than io.EOF
+}
+
+func newLexer(r io.Reader) *lexer {
+	return &lexer{
+		r:   bufio.NewReader(r),
+		pos: ast.Position{Line: 1, Column: 1},
+	}
+}
+
+func (l *lexer) peekRune() (rune, bool) {
+	if l.err != nil {
+		return 0, false
+	}
+	c, _, err := l.r.ReadRune()
+	if err != nil {
+		if err != io.EOF {
+			l.err = err
+		}
+		return 0, false
+	}
+	l.r.UnreadRune()
+	return c, true
+}
+
+func (l *lexer) readRune() (rune, bool) {
+	if l.err != nil {
+		return 0, false
+	}
+	c, size, err := l.r.ReadRune()
+	if err != nil {
+		if err != io.EOF {
+			l.err = err
+		}
+		return 0, false
+	}
+	if c == '\n' {
+		l.pos.Line++
+		l.pos.Column = 1
+	} else {
+		l.pos.Column += size
+	}
+	return c, true
+}
+
+// skipSpace skips blanks, including line breaks when newlines is set.
+func (l *lexer) skipSpace(newlines bool) {
+	for {
+		c, ok := l.peekRune()
+		if !ok || !unicode.IsSpace(c) || (c == '\n' && !newlines) {
+			return
+		}
+		l.readRune()
+	}
+}
+
+func isIdentRune(c rune) bool {
+	return c == '_' || c == '-' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func (l *lexer) next() token {
+	l.skipSpace(true)
+
+	pos := l.pos
+	c, ok := l.readRune()
+	if !ok {
+		return token{kind: tokEOF, pos: pos}
+	}
+
+	switch c {
+	case ':':
+		return token{kind: tokColon, text: ":", pos: pos}
+	case '(':
+		return token{kind: tokLParen, text: "(", pos: pos}
+	case '{':
+		return token{kind: tokLBrace, text: "{", pos: pos}
+	case '}':
+		return token{kind: tokRBrace, text: "}", pos: pos}
+	case '!':
+		return token{kind: tokDirective, text: l.readWhile(isIdentRune), pos: pos}
+	}
+
+	if isIdentRune(c) {
+		return token{kind: tokIdent, text: string(c) + l.readWhile(isIdentRune), pos: pos}
+	}
+
+	return token{kind: tokOther, text: string(c) + l.readWhile(func(c rune) bool {
+		return !unicode.IsSpace(c) && !strings.ContainsRune(":(){}", c)
+	}), pos: pos}
+}
+
+func (l *lexer) readWhile(accept func(rune) bool) string {
+	var b strings.Builder
+	for {
+		c, ok := l.peekRune()
+		if !ok || !accept(c) {
+			return b.String()
+		}
+		l.readRune()
+		b.WriteRune(c)
+	}
+}
+
+// peekOnLine reports whether the next non-blank rune on the current line is
+// want, without consuming it.
+func (l *lexer) peekOnLine(want rune) bool {
+	l.skipSpace(false)
+	c, ok := l.peekRune()
+	return ok && c == want
+}
+
+func (l *lexer) peekParen() bool {
+	return l.peekOnLine('(')
+}
+
+func (l *lexer) peekBrace() bool {
+	return l.peekOnLine('{')
+}
+
+func (l *lexer) peekColon() bool {
+	return l.peekOnLine(':')
+}
+
+// restOfLine returns the remainder of the current line without surrounding
+// blanks and consumes the line break.
+func (l *lexer) restOfLine() string {
+	line := l.readWhile(func(c rune) bool { return c != '\n' })
+	l.readRune()
+	return strings.TrimSpace(line)
+}
+
+// skipLine skips the rest of the current line for error recovery. It stops
+// before a closing brace so that the enclosing block still ends where the
+// author meant it to.
+func (l *lexer) skipLine() string {
+	skipped := l.readWhile(func(c rune) bool { return c != '\n' && c != '}' })
+	if c, ok := l.peekRune(); ok && c == '\n' {
+		l.readRune()
+	}
+	return strings.TrimRight(skipped, " \t\r")
+}
+
+// parenArg reads a parenthesised argument that starts at the next rune.
+// Nested parentheses are kept, so links such as .../Foo_(bar) survive. The
+// argument may not span lines: if the line ends first, parenArg returns what
+// it read and false, leaving the line break unread.
+func (l *lexer) parenArg() (string, bool) {
+	if c, ok := l.peekRune(); !ok || c != '(' {
+		return "", false
+	}
+	l.readRune()
+
+	var b strings.Builder
+	depth := 1
+	for {
+		c, ok := l.peekRune()
+		if !ok || c == '\n' {
+			return b.String(), false
+		}
+		l.readRune()
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return b.String(), true
+			}
+		}
+		b.WriteRune(c)
+	}
+}
+
+// blockBody reads the raw body of a block whose opening brace has already
+// been consumed, up to the matching closing brace. It returns the body, the
+// position of the closing brace and whether one was found before the end of
+// input.
+func (l *lexer) blockBody() (string, ast.Position, bool) {
+	var b strings.Builder
+	depth := 1
+	for {
+		pos := l.pos
+		c, ok := l.readRune()
+		if !ok {
+			return b.String(), pos, false
+		}
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return b.String(), pos, true
+			}
+		}
+		b.WriteRune(c)
+	}
+}
+
+// verbatimBody reads the body of a code block whose opening brace has
+// already been consumed. Braces in code are not counted and need not
+// balance: the body ends at the first line holding nothing but a closing
+// brace, indented no deeper than indent bytes. The lines of the code must
+// therefore be indented deeper than the block; shallow is the position of
+// the first non-blank line that is not, or the zero Position. A body that
+// starts on the line of the opening brace is read like any other block
+// instead. The other results are what blockBody returns.
+func (l *lexer) verbatimBody(indent int) (body string, end, shallow ast.Position, ok bool) {
+	l.skipSpace(false)
+	if c, ok := l.peekRune(); ok && c != '\n' {
+		body, end, ok := l.blockBody()
+		return strings.TrimRight(body, " \t"), end, ast.Position{}, ok
+	}
+	l.readRune()
+
+	var b strings.Builder
+	for {
+		start := l.pos
+		line := l.readWhile(func(c rune) bool { return c != '\n' })
+		_, more := l.readRune()
+
+		trimmed := strings.TrimLeft(line, " \t")
+		depth := len(line) - len(trimmed)
+		if strings.TrimSpace(trimmed) == "}" && depth <= indent {
+			start.Column += depth
+			return b.String(), start, shallow, true
+		}
+		if strings.TrimSpace(trimmed) != "" && depth <= indent && !shallow.IsValid() {
+			shallow = ast.Position{Line: start.Line, Column: depth + 1}
+		}
+
+		b.WriteString(line)
+		if !more {
+			return b.String(), l.pos, shallow, false
+		}
+		b.WriteByte('\n')
+	}
+}
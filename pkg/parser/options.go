@@ -0,0 +1,125 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package parser
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+)
+
+// DefaultWebographyName is the file ParsePath looks for next to a document
+// when no webography was given.
+const DefaultWebographyName = "webography"
+
+// Options configures a Parser.
+type Options struct {
+	// Webography resolves ${keyword} references. When nil, Parse uses an
+	// empty one and ParsePath loads the webography next to the document.
+	// Webography blocks inside the document override its entries.
+	Webography *ast.Webography
+	// Dir is where files named in the document, such as the CSV file of
+	// table(data.csv), are looked up. ParsePath defaults it to the
+	// document's directory, Parse to the working directory.
+	Dir string
+}
+
+// Parser parses documents with a fixed set of options. It keeps no state
+// between parses and may be used from several goroutines at once.
+type Parser struct {
+	opts Options
+}
+
+func New(opts Options) *Parser {
+	return &Parser{opts: opts}
+}
+
+// Parse reads a whole document from r. Problems in the document are reported
+// as a diag.List; the returned document holds whatever could be parsed and is
+// never nil. Read errors from r are returned as they are.
+func (p *Parser) Parse(r io.Reader) (*ast.Document, error) {
+	bib := p.opts.Webography
+	if bib == nil {
+		bib = ast.NewWebography()
+	}
+	return parse(r, bib, p.opts.Dir)
+}
+
+// ParsePath parses the document stored at path. Without a webography in the
+// options it loads DefaultWebographyName from the document's directory, if
+// there is one.
+func (p *Parser) ParsePath(path string) (*ast.Document, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	bib := p.opts.Webography
+	if bib == nil {
+		bib, err = LoadWebography(WebographyPath(path))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	dir := p.opts.Dir
+	if dir == "" {
+		dir = filepath.Dir(path)
+	}
+	return parse(file, bib, dir)
+}
+
+// WebographyPath returns where the default webography of the document at
+// docPath lives.
+func WebographyPath(docPath string) string {
+	return filepath.Join(filepath.Dir(docPath), DefaultWebographyName)
+}
+
+// LoadWebography reads the webography at path. The returned webography is
+// never nil, so callers may ignore a missing file.
+func LoadWebography(path string) (*ast.Webography, error) {
+	bib := ast.NewWebography()
+	return bib, bib.LoadFromFile(path)
+}
+
+// parse parses a document against the external webography bib, reading the
+// files it names from dir. Entries in webography blocks of the document take
+// precedence over entries of bib with the same keyword; bib itself is left
+// untouched.
+func parse(r io.Reader, bib *ast.Webography, dir string) (*ast.Document, error) {
+	p := newParser(r)
+	p.dir = dir
+	doc := p.parseDocument()
+	doc.Webography = bib
+	if p.bib != nil {
+		doc.Webography = ast.NewWebography()
+		doc.Webography.Merge(bib)
+		doc.Webography.Merge(p.bib)
+	}
+
+	if p.lex.err != nil {
+		return doc, p.lex.err
+	}
+	return doc, p.diags.Err()
+}
@@ -19,311 +19,619 @@ along with nanami. If not, see <https://www.gnu.org/licenses/>.
 package parser
 
 import (
-	"fmt"
+	"io"
 	"strings"
 
 	"github.com/nanomarkdown/nanami/pkg/ast"
-	stringUtil "github.com/nanomarkdown/nanami/pkg/common/strings"
+	"github.com/nanomarkdown/nanami/pkg/diag"
 )
 
-var globalBib *ast.Webography
+// parser is a recursive-descent parser over the tokens of a single document.
+type parser struct {
+	lex    *lexer
+	diags  diag.List
+	peeked *token
+	last   ast.Position // position of the last token consumed
+	// bib collects the entries of webography blocks in the document.
+	bib *ast.Webography
+	// dir is where files named in the document are read from.
+	dir string
+	// figures maps the IDs of the figures read so far to their positions.
+	figures  map[string]ast.Position
+	nfigures int
+}
+
+func newParser(r io.Reader) *parser {
+	return &parser{lex: newLexer(r)}
+}
+
+// Parse reads a whole document from r with the default options.
+func Parse(r io.Reader) (*ast.Document, error) {
+	return New(Options{}).Parse(r)
+}
 
+// ParseFile parses a document that has already been split into lines.
 func ParseFile(lines []string) (*ast.Document, error) {
-	globalBib = new(ast.Webography)
-	globalBib.LoadFromFile("webography")
+	return Parse(strings.NewReader(strings.Join(lines, "\n")))
+}
+
+func (p *parser) next() token {
+	if p.peeked != nil {
+		tok := *p.peeked
+		p.peeked = nil
+		return tok
+	}
+	tok := p.lex.next()
+	if tok.kind != tokEOF {
+		p.last = tok.pos
+	}
+	return tok
+}
+
+// backup pushes tok back so that the next call to next returns it again.
+func (p *parser) backup(tok token) {
+	p.peeked = &tok
+}
 
+func (p *parser) parseDocument() *ast.Document {
 	doc := &ast.Document{
 		Title:   "",
 		Content: []ast.Node{},
-		Cases:   []ast.CaseNode{},
 		NoNLP:   false,
 	}
+	add := func(n ast.Node) {
+		doc.Content = append(doc.Content, n)
+	}
 
-	i := 0
-
-	for i < len(lines) {
-		line := strings.TrimSpace(lines[i])
+	for {
+		tok := p.next()
+		if tok.kind != tokEOF && !doc.From.IsValid() {
+			doc.From = tok.pos
+		}
 
-		if strings.HasPrefix(line, "title:") {
-			doc.Title = strings.TrimSpace(line[6:])
-			i++
-		} else if line == "!nlp" {
+		switch {
+		case tok.kind == tokEOF:
+			doc.To = p.last
+			return doc
+		case tok.kind == tokIdent && tok.text == "title" && p.lex.peekColon():
+			p.lex.readRune()
+			doc.Title = p.lex.restOfLine()
+		case tok.kind == tokIdent && tok.text == "style" && p.lex.peekColon():
+			p.lex.readRune()
+			doc.Style = p.lex.restOfLine()
+		case tok.kind == tokDirective && tok.text == "nlp":
 			doc.NoNLP = true
-			i++
-		} else if line == "content {" {
-			i++
-			break
-		} else if line == "" {
-			i++
-		} else {
-			break
+		case tok.kind == tokDirective:
+			p.warnf(tok.pos, CodeUnknownDirective, "unknown directive \"!%s\"", tok.text)
+		case tok.kind == tokIdent && tok.text == "content":
+			if p.expectBrace(tok) {
+				if _, ok := p.parseBody(add); !ok {
+					p.errorf(tok.pos, CodeUnterminatedBlock, "unterminated content block")
+				}
+			}
+		case tok.kind == tokRBrace:
+			p.errorf(tok.pos, CodeStrayBrace, "unexpected }")
+		default:
+			p.parseBlock(tok, add)
 		}
 	}
-
-	parseContentBody(lines, i, doc)
-
-	return doc, nil
 }
 
-func parseContentBody(lines []string, start int, doc *ast.Document) int {
-	i := start
-
-	for i < len(lines) {
-		line := strings.TrimSpace(lines[i])
-
-		if line == "}" {
-			return i + 1
-		} else if strings.HasPrefix(line, "case(") {
-			caseNode, newI := parseCase(lines, i, doc)
-			doc.Cases = append(doc.Cases, *caseNode)
-			i = newI
-		} else if line == "text {" {
-			textBlock, newI := parseTextBlock(lines, i, false)
-			doc.Content = append(doc.Content, textBlock)
-			i = newI
-		} else if line == "sources {" {
-			sourcesBlock, newI := parseSourcesBlock(lines, i)
-			doc.Content = append(doc.Content, sourcesBlock)
-			i = newI
-		} else {
-			i++
+// parseBody parses blocks up to the brace closing the enclosing block and
+// hands each of them to add. It returns the position of the closing brace
+// and false if the input ended first.
+func (p *parser) parseBody(add func(ast.Node)) (ast.Position, bool) {
+	for {
+		tok := p.next()
+		switch tok.kind {
+		case tokEOF:
+			return p.last, false
+		case tokRBrace:
+			return tok.pos, true
+		default:
+			p.parseBlock(tok, add)
 		}
 	}
-
-	return i
 }
 
-func parseCase(lines []string, start int, doc *ast.Document) (*ast.CaseNode, int) {
-	i := start
-	line := strings.TrimSpace(lines[i])
+// parseBlock parses the block introduced by tok. Anything that is not a
+// known block is reported and skipped.
+func (p *parser) parseBlock(tok token, add func(ast.Node)) {
+	if tok.kind != tokIdent {
+		p.errorf(tok.pos, CodeUnknownDirective, "unknown directive %q", tok.text+p.lex.skipLine())
+		return
+	}
 
-	caseNode := &ast.CaseNode{
-		Title:    "",
-		Link:     "",
-		Body:     []ast.Node{},
-		SubCases: []ast.CaseNode{},
-	}
-
-	if strings.HasPrefix(line, "case(") {
-		titleStart := 5
-		titleEnd := strings.Index(line[titleStart:], ")")
-		if titleEnd != -1 {
-			caseNode.Title = line[titleStart : titleStart+titleEnd]
-
-			remaining := line[titleStart+titleEnd+1:]
-			if strings.HasPrefix(remaining, "(") && strings.Contains(remaining, ") {") {
-				linkEnd := strings.Index(remaining[1:], ")")
-				if linkEnd != -1 {
-					caseNode.Link = remaining[1 : linkEnd+1]
-				}
-			}
+	switch tok.text {
+	case "case":
+		add(p.parseCase(tok))
+	case "text":
+		textBlock := p.parseTextOptions(tok)
+		if p.expectBrace(tok) {
+			add(p.parseTextBlock(tok, textBlock))
+		}
+	case "sources":
+		if p.expectBrace(tok) {
+			add(p.parseSourcesBlock(tok))
+		}
+	case "html":
+		if p.expectBrace(tok) {
+			add(p.parseHTMLBlock(tok))
 		}
+	case "code":
+		add(p.parseCodeBlock(tok))
+	case "table":
+		add(p.parseTableBlock(tok))
+	case "figure":
+		add(p.parseFigureBlock(tok))
+	case "quote":
+		add(p.parseQuoteBlock(tok))
+	case "note", "warning", "aside":
+		add(p.parseCalloutBlock(tok))
+	case "list", "olist":
+		if p.expectBrace(tok) {
+			add(p.parseListBlock(tok))
+		}
+	case "webography", "references":
+		if p.expectBrace(tok) {
+			p.parseWebographyBlock(tok)
+		}
+	default:
+		p.errorf(tok.pos, CodeUnknownDirective, "unknown directive %q", tok.text)
+		p.skipUnknown()
 	}
+}
 
-	i++
-
-	for i < len(lines) {
-		line := strings.TrimSpace(lines[i])
-
-		if line == "}" {
-			return caseNode, i + 1
-		} else if line == "text {" {
-			textBlock, newI := parseTextBlock(lines, i, false)
-			caseNode.Body = append(caseNode.Body, textBlock)
-			i = newI
-		} else if line == "sources {" {
-			sourcesBlock, newI := parseSourcesBlock(lines, i)
-			caseNode.Body = append(caseNode.Body, sourcesBlock)
-			i = newI
-		} else if strings.HasPrefix(line, "case(") {
-			subCase, newI := parseCase(lines, i, doc)
-			caseNode.SubCases = append(caseNode.SubCases, *subCase)
-			i = newI
-		} else {
-			i++
-		}
+// skipUnknown skips whatever follows an unknown directive: its block if it
+// opens one on the same line, or else the rest of the line.
+func (p *parser) skipUnknown() {
+	if p.lex.peekBrace() {
+		p.lex.readRune()
+		_, p.last, _ = p.lex.blockBody()
+		return
 	}
+	p.lex.skipLine()
+}
 
-	return caseNode, i
+// expectBrace consumes the opening brace of the block introduced by tok.
+func (p *parser) expectBrace(tok token) bool {
+	brace := p.next()
+	if brace.kind == tokLBrace {
+		return true
+	}
+	p.errorf(brace.pos, CodeMissingBrace, "expected { after %s", tok.text)
+	p.backup(brace)
+	return false
 }
 
-func parseTextBlock(lines []string, start int, noNLP bool) (*ast.TextNode, int) {
-	i := start + 1
+func (p *parser) parseCase(tok token) *ast.CaseNode {
+	caseNode := &ast.CaseNode{
+		Span:  ast.Span{From: tok.pos},
+		Title: "",
+		Link:  "",
+		Body:  []ast.Node{},
+	}
 
-	textBlock := &ast.TextNode{
-		Content: "",
-		NoNLP:   noNLP,
+	if !p.parseCaseHeader(caseNode) {
+		caseNode.To = p.last
+		return caseNode
 	}
 
-	var contentLines []string
+	add := func(n ast.Node) {
+		caseNode.Body = append(caseNode.Body, n)
+	}
 
-	for i < len(lines) {
-		line := strings.TrimSpace(lines[i])
+	end, ok := p.parseBody(add)
+	if !ok {
+		p.errorf(caseNode.From, CodeUnterminatedBlock, "unterminated case(%s) block", caseNode.Title)
+	}
+	caseNode.To = end
 
-		if line == "}" {
-			break
-		} else if line != "" {
-			contentLines = append(contentLines, line)
-		}
-		i++
+	return caseNode
+}
+
+// parseCaseHeader reads the (title)(link) { part of a case and reports
+// whether the body that follows should be parsed. A malformed header is
+// reported; its body is still parsed when the header line ends in a brace.
+func (p *parser) parseCaseHeader(caseNode *ast.CaseNode) bool {
+	malformed := func() {
+		p.errorf(caseNode.From, CodeMalformedCase,
+			"malformed case header, expected case(title) { or case(title)(link) {")
 	}
 
-	textBlock.Content = strings.Join(contentLines, " ")
+	if !p.lex.peekParen() {
+		malformed()
+		return false
+	}
+	title, ok := p.lex.parenArg()
+	caseNode.Title = title
+	if ok && p.lex.peekParen() {
+		caseNode.Link, ok = p.lex.parenArg()
+		if !ok {
+			// Keep what was read so that the rest of the line can be checked.
+			title = caseNode.Link
+		}
+	}
+	if !ok {
+		malformed()
+		return strings.HasSuffix(strings.TrimSpace(title), "{")
+	}
 
-	return textBlock, i + 1
+	brace := p.next()
+	if brace.kind != tokLBrace {
+		malformed()
+		p.backup(brace)
+		return false
+	}
+	return true
 }
 
-func parseSourcesBlock(lines []string, start int) (*ast.SourcesNode, int) {
-	i := start + 1
+// parseTextOptions reads the optional (nlp) or (!nlp) after text.
+func (p *parser) parseTextOptions(tok token) *ast.TextNode {
+	textBlock := &ast.TextNode{Span: ast.Span{From: tok.pos}}
+	if !p.lex.peekParen() {
+		return textBlock
+	}
 
-	sourcesBlock := &ast.SourcesNode{
-		Content: "",
+	option, _ := p.lex.parenArg()
+	switch strings.TrimSpace(option) {
+	case "nlp":
+		textBlock.NLP = true
+	case "!nlp":
+		textBlock.NoNLP = true
+	default:
+		p.warnf(tok.pos, CodeUnknownDirective, "unknown text option %q, expected nlp or !nlp", option)
 	}
+	return textBlock
+}
 
-	var contentLines []string
+// parseTextBlock fills in the body of textBlock, which holds the options
+// read by parseTextOptions.
+func (p *parser) parseTextBlock(tok token, textBlock *ast.TextNode) *ast.TextNode {
+	body, end := p.parseLeafBody(tok)
+	textBlock.Content = paragraphs(body)
+	textBlock.Paragraphs = ParseParagraphs(textBlock.Content)
+	textBlock.To = end
 
-	for i < len(lines) {
-		line := strings.TrimSpace(lines[i])
+	return textBlock
+}
 
-		if line == "}" {
-			break
-		} else if line != "" {
-			contentLines = append(contentLines, line)
-		}
-		i++
+func (p *parser) parseSourcesBlock(tok token) *ast.SourcesNode {
+	sourcesBlock := &ast.SourcesNode{
+		Span:    ast.Span{From: tok.pos},
+		Content: "",
 	}
 
-	sourcesBlock.Content = strings.Join(contentLines, " ")
+	body, end := p.parseLeafBody(tok)
+	sourcesBlock.Content = joinLines(body)
+	sourcesBlock.Inline = ParseInline(sourcesBlock.Content)
+	sourcesBlock.To = end
 
-	return sourcesBlock, i + 1
+	return sourcesBlock
 }
 
-func ParseInlineElements(content string) string {
-	result := strings.Builder{}
-	result.Grow(len(content) * 2)
+// parseFigureBlock reads a figure(id) block and numbers it. Besides blocks,
+// its body may hold a caption: line and lines starting with a brace, such
+// as images, which are read as inline content.
+func (p *parser) parseFigureBlock(tok token) *ast.FigureNode {
+	p.nfigures++
+	figure := &ast.FigureNode{Span: ast.Span{From: tok.pos}, Number: p.nfigures}
+	if p.lex.peekParen() {
+		id, _ := p.lex.parenArg()
+		figure.ID = p.figureID(tok, strings.TrimSpace(id))
+	}
+	if !p.expectBrace(tok) {
+		figure.To = p.last
+		return figure
+	}
 
-	i := 0
-	for i < len(content) {
+	var lines []string
+	add := func(n ast.Node) {
+		figure.Body = append(figure.Body, n)
+	}
+body:
+	for {
+		next := p.next()
 		switch {
-		case content[i] == '{':
-			if newI, replacement, found := tryParseImage(content, i); found {
-				result.WriteString(replacement)
-				i = newI
-			} else if newI, replacement, found := tryParseLink(content, i); found {
-				result.WriteString(replacement)
-				i = newI
-			} else if newI, replacement, found := tryParseFootnotes(content, i); found {
-				result.WriteString(replacement)
-				i = newI
-			} else {
-				result.WriteByte(content[i])
-				i++
-			}
-		case content[i] == '$' && i+1 < len(content) && content[i+1] == '{':
-			if newI, replacement, found := tryParseReference(content, i); found {
-				result.WriteString(replacement)
-				i = newI
-			} else {
-				result.WriteByte(content[i])
-				i++
-			}
+		case next.kind == tokEOF:
+			p.errorf(tok.pos, CodeUnterminatedBlock, "unterminated figure block")
+			figure.To = p.last
+			break body
+		case next.kind == tokRBrace:
+			figure.To = next.pos
+			break body
+		case next.kind == tokIdent && next.text == "caption" && p.lex.peekColon():
+			p.lex.readRune()
+			figure.Caption = ParseInline(p.lex.restOfLine())
+		case next.kind == tokLBrace:
+			lines = append(lines, "{"+p.lex.restOfLine())
 		default:
-			result.WriteByte(content[i])
-			i++
+			p.parseBlock(next, add)
 		}
 	}
+	figure.Content = ParseInline(strings.Join(lines, " "))
 
-	return result.String()
+	return figure
 }
 
-func tryParseImage(content string, start int) (int, string, bool) {
-	if start+5 >= len(content) || content[start:start+5] != "{img/" {
-		return start, "", false
+// figureID checks the ID of a figure and returns it, or "" for IDs that
+// cannot serve as anchors. IDs that are already taken are reported but
+// kept; references go to the first figure with the ID.
+func (p *parser) figureID(tok token, id string) string {
+	if id == "" {
+		return ""
 	}
-
-	pathEnd := stringUtil.FindClosingBrace(content, start+1)
-	if pathEnd == -1 {
-		return start, "", false
+	if strings.ContainsAny(id, " \t") {
+		p.errorf(tok.pos, CodeBadFigure, "figure id %q may not contain blanks", id)
+		return ""
+	}
+	if first, ok := p.figures[id]; ok {
+		p.errorf(tok.pos, CodeBadFigure, "figure id %q is already used on line %d", id, first.Line)
+		return id
+	}
+	if p.figures == nil {
+		p.figures = map[string]ast.Position{}
 	}
+	p.figures[id] = tok.pos
+	return id
+}
 
-	if pathEnd+1 >= len(content) || content[pathEnd+1] != '{' {
-		return start, "", false
+// parseQuoteBlock reads a quote block and the attribution that may follow
+// quote in parentheses.
+func (p *parser) parseQuoteBlock(tok token) *ast.QuoteNode {
+	quote := &ast.QuoteNode{Span: ast.Span{From: tok.pos}}
+	if p.lex.peekParen() {
+		attribution, _ := p.lex.parenArg()
+		quote.Attribution = ParseInline(strings.TrimSpace(attribution))
+	}
+	if !p.expectBrace(tok) {
+		quote.To = p.last
+		return quote
 	}
 
-	altEnd := stringUtil.FindClosingBrace(content, pathEnd+2)
-	if altEnd == -1 {
-		return start, "", false
+	body, end := p.parseLeafBody(tok)
+	quote.Content = paragraphs(body)
+	quote.Paragraphs = ParseParagraphs(quote.Content)
+	quote.To = end
+
+	return quote
+}
+
+var calloutTypes = map[string]ast.CalloutType{
+	"note":    ast.CalloutNote,
+	"warning": ast.CalloutWarning,
+	"aside":   ast.CalloutAside,
+}
+
+// parseCalloutBlock reads a note, warning or aside block and the title
+// that may follow its name in parentheses.
+func (p *parser) parseCalloutBlock(tok token) *ast.CalloutNode {
+	callout := &ast.CalloutNode{Span: ast.Span{From: tok.pos}, Type: calloutTypes[tok.text]}
+	if p.lex.peekParen() {
+		title, _ := p.lex.parenArg()
+		callout.Title = ParseInline(strings.TrimSpace(title))
+	}
+	if !p.expectBrace(tok) {
+		callout.To = p.last
+		return callout
 	}
 
-	imagePath := content[start+5 : pathEnd]
-	altText := content[pathEnd+2 : altEnd]
+	body, end := p.parseLeafBody(tok)
+	callout.Content = paragraphs(body)
+	callout.Paragraphs = ParseParagraphs(callout.Content)
+	callout.To = end
 
-	replacement := fmt.Sprintf(`<img src="%s" alt="%s"/>`,
-		imagePath,
-		altText)
+	return callout
+}
+
+// parseHTMLBlock reads a block of raw HTML. Its lines are kept apart but
+// trimmed like those of other blocks.
+func (p *parser) parseHTMLBlock(tok token) *ast.HTMLNode {
+	htmlBlock := &ast.HTMLNode{Span: ast.Span{From: tok.pos}}
+
+	body, end := p.parseLeafBody(tok)
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	htmlBlock.Content = strings.Join(lines, "\n")
+	htmlBlock.To = end
 
-	return altEnd + 1, replacement, true
+	return htmlBlock
 }
 
-func tryParseLink(content string, start int) (int, string, bool) {
-	if start+6 >= len(content) || !stringUtil.StartsWithHttp(content, start+1) {
-		return start, "", false
+// parseCodeBlock reads a code(lang) { ... } block. Its body is kept
+// verbatim apart from the indentation its lines share. The block ends at
+// the first line holding only } that is indented no deeper than code, so
+// code lines indented no deeper than that are reported.
+func (p *parser) parseCodeBlock(tok token) *ast.CodeNode {
+	codeBlock := &ast.CodeNode{Span: ast.Span{From: tok.pos}}
+	if p.lex.peekParen() {
+		lang, _ := p.lex.parenArg()
+		codeBlock.Lang = strings.TrimSpace(lang)
+	}
+	if !p.expectBrace(tok) {
+		codeBlock.To = p.last
+		return codeBlock
 	}
 
-	urlEnd := stringUtil.FindClosingBrace(content, start+1)
-	if urlEnd == -1 {
-		return start, "", false
+	body, end, shallow, ok := p.lex.verbatimBody(tok.pos.Column - 1)
+	p.last = end
+	if shallow.IsValid() {
+		p.errorf(tok.pos, CodeCodeIndent,
+			"line %d of the code block must be indented deeper than code, or a } in the code ends the block early",
+			shallow.Line)
 	}
+	if !ok {
+		p.errorf(tok.pos, CodeUnterminatedBlock, "unterminated code block")
+	}
+	codeBlock.Content = dedent(body)
+	codeBlock.To = end
 
-	url := content[start+1 : urlEnd]
-	linkText := url // Default link text is the URL
-	endPos := urlEnd + 1
+	return codeBlock
+}
 
-	if endPos < len(content) && content[endPos] == '{' {
-		textEnd := stringUtil.FindClosingBrace(content, endPos+1)
-		if textEnd != -1 {
-			linkText = content[endPos+1 : textEnd]
-			endPos = textEnd + 1
-		}
+// dedent removes the leading blanks shared by the non-blank lines of s,
+// along with blank lines at its start and end.
+func dedent(s string) string {
+	lines := strings.Split(s, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
 	}
 
-	replacement := fmt.Sprintf(`<a href="%s">%s</a>`,
-		url,
-		linkText)
+	prefix, first := "", true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
 
-	return endPos, replacement, true
+	for i, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			lines[i] = line[len(prefix):]
+		} else {
+			// A blank line shorter than the shared indentation.
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
 }
 
-func tryParseReference(content string, start int) (int, string, bool) {
-	if start+2 >= len(content) || content[start:start+2] != "${" {
-		return start, "", false
+// parseListBlock reads a list { ... } or olist { ... } block, one item per
+// line.
+func (p *parser) parseListBlock(tok token) *ast.ListNode {
+	list := &ast.ListNode{Span: ast.Span{From: tok.pos}, Ordered: tok.text == "olist"}
+	firstLine := p.last.Line
+
+	body, end := p.parseLeafBody(tok)
+	lines := strings.Split(body, "\n")
+	for i := p.parseListLines(list, lines, firstLine, 0); i < len(lines); i = p.parseListLines(list, lines, firstLine, i+1) {
+		p.errorf(ast.Position{Line: firstLine + i, Column: strings.IndexByte(lines[i], '}') + 1},
+			CodeStrayBrace, "unexpected } in list")
 	}
+	list.To = end
+
+	return list
+}
+
+// parseListLines adds the items in lines, starting at index i, to list.
+// Line i of the body is line firstLine+i of the document. It stops at a
+// line holding only } and returns its index, or len(lines) at the end of
+// the body.
+func (p *parser) parseListLines(list *ast.ListNode, lines []string, firstLine, i int) int {
+	for ; i < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+		pos := ast.Position{Line: firstLine + i, Column: len(lines[i]) - len(strings.TrimLeft(lines[i], " \t")) + 1}
+
+		switch text {
+		case "":
+			continue
+		case "}":
+			return i
+		case "list {", "list{", "olist {", "olist{":
+			sublist := &ast.ListNode{Span: ast.Span{From: pos}, Ordered: strings.HasPrefix(text, "olist")}
+			i = p.parseListLines(sublist, lines, firstLine, i+1)
+			if i == len(lines) {
+				p.errorf(pos, CodeUnterminatedBlock, "unterminated sublist")
+			} else {
+				sublist.To = ast.Position{Line: firstLine + i, Column: strings.IndexByte(lines[i], '}') + 1}
+			}
 
-	keywordEnd := stringUtil.FindClosingBrace(content, start+2)
-	if keywordEnd == -1 {
-		return start, "", false
+			if len(list.Items) == 0 {
+				list.Items = append(list.Items, &ast.ListItem{})
+			}
+			item := list.Items[len(list.Items)-1]
+			item.Sublists = append(item.Sublists, sublist)
+		default:
+			list.Items = append(list.Items, &ast.ListItem{Text: text, Content: ParseInline(text)})
+		}
 	}
+	return i
+}
 
-	keyword := content[start+2 : keywordEnd]
+// parseWebographyBlock reads a block of webography entries in the same
+// format as a webography file. The entries end up in Document.Webography.
+func (p *parser) parseWebographyBlock(tok token) {
+	firstLine := p.last.Line
+	body, _ := p.parseLeafBody(tok)
 
-	var replacement string
-	if globalBib != nil {
-		replacement = globalBib.GetReference(keyword)
+	if p.bib == nil {
+		p.bib = ast.NewWebography()
 	}
+	p.bib.LoadEmbedded(strings.NewReader(body), firstLine)
+}
 
-	return keywordEnd + 1, replacement, true
+// parseLeafBody reads the raw body of a block that holds inline content
+// rather than other blocks.
+func (p *parser) parseLeafBody(tok token) (string, ast.Position) {
+	body, end, ok := p.lex.blockBody()
+	p.last = end
+	if !ok {
+		p.errorf(tok.pos, CodeUnterminatedBlock, "unterminated %s block", tok.text)
+	}
+	return body, end
 }
 
-func tryParseFootnotes(content string, start int) (int, string, bool) {
-	footnotesStr := "{footnotes}"
-	if start+len(footnotesStr) > len(content) || content[start:start+len(footnotesStr)] != footnotesStr {
-		return start, "", false
+// paragraphs lays out the body of a text block: blank lines separate
+// paragraphs, which are kept apart by a blank line, and the other lines
+// are joined with single spaces, or with a newline where a line ends in a
+// backslash to force a line break.
+func paragraphs(body string) string {
+	var result, paragraph strings.Builder
+	hardBreak := false
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if paragraph.Len() > 0 {
+				if result.Len() > 0 {
+					result.WriteString("\n\n")
+				}
+				result.WriteString(paragraph.String())
+				paragraph.Reset()
+			}
+			hardBreak = false
+			continue
+		}
+
+		if paragraph.Len() > 0 {
+			if hardBreak {
+				paragraph.WriteString("\n")
+			} else {
+				paragraph.WriteString(" ")
+			}
+		}
+		line, hardBreak = strings.CutSuffix(line, "\\")
+		paragraph.WriteString(strings.TrimSpace(line))
 	}
 
-	var replacement string
-	if globalBib != nil {
-		replacement = globalBib.GenerateFootnotes()
+	if paragraph.Len() > 0 {
+		if result.Len() > 0 {
+			result.WriteString("\n\n")
+		}
+		result.WriteString(paragraph.String())
 	}
+	return result.String()
+}
 
-	return start + len(footnotesStr), replacement, true
+// joinLines joins the non-empty lines of a block body with single spaces.
+func joinLines(body string) string {
+	var contentLines []string
+	for _, line := range strings.Split(body, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			contentLines = append(contentLines, line)
+		}
+	}
+	return strings.Join(contentLines, " ")
 }
@@ -19,9 +19,15 @@ along with nanami. If not, see <https://www.gnu.org/licenses/>.
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+	"github.com/nanomarkdown/nanami/pkg/diag"
 )
 
 func TestParseTextBlock(t *testing.T) {
@@ -29,15 +35,629 @@ func TestParseTextBlock(t *testing.T) {
 	textBlock := fmt.Sprintf(`text {
 		%s
 	}`, text)
-	lines := strings.Split(textBlock, "\n")
 
-	res, count := parseTextBlock(lines, 0, false)
+	p := newParser(strings.NewReader(textBlock))
+	tok := p.next()
+	opts := p.parseTextOptions(tok)
+	if !p.expectBrace(tok) {
+		t.Fatalf("Expected an opening brace after %q", tok.text)
+	}
+	res := p.parseTextBlock(tok, opts)
 
 	if res.Content != text {
 		t.Errorf("Expected text '%s', got '%s'", text, res.Content)
 	}
 
-	if count != len(lines) {
-		t.Errorf("Expected count of %d, got %d", len(lines), count)
+	if tok := p.next(); tok.kind != tokEOF {
+		t.Errorf("Expected the whole block to be consumed, got %q", tok.text)
+	}
+}
+
+func TestParseFilePositions(t *testing.T) {
+	lines := strings.Split(`title: positions
+content {
+	case(hello) {
+		text {
+			I exist!
+		}
+	}
+}`, "\n")
+
+	doc, err := ParseFile(lines)
+	if err != nil {
+		t.Fatalf("ParseFile returned %v", err)
+	}
+
+	if len(doc.Cases()) != 1 {
+		t.Fatalf("Expected 1 case, got %d", len(doc.Cases()))
+	}
+	caseNode := doc.Cases()[0]
+	if want := (ast.Position{Line: 3, Column: 2}); caseNode.Start() != want {
+		t.Errorf("Expected case to start at %v, got %v", want, caseNode.Start())
+	}
+	if want := (ast.Position{Line: 7, Column: 2}); caseNode.End() != want {
+		t.Errorf("Expected case to end at %v, got %v", want, caseNode.End())
+	}
+
+	children := caseNode.Children()
+	if len(children) != 1 || children[0].Kind() != ast.KindText {
+		t.Fatalf("Expected a single text child, got %v", children)
+	}
+	if want := (ast.Position{Line: 4, Column: 3}); children[0].Start() != want {
+		t.Errorf("Expected text to start at %v, got %v", want, children[0].Start())
+	}
+}
+
+func TestParseFileDiagnostics(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{
+			input: `title: broken
+content {
+	txt { dropped }
+	case(hello)(https://example.com {
+		text {
+			I exist!
+		}
+	}
+}
+}`,
+			want: []string{
+				"3:2: unknown directive \"txt\" [unknown-directive]",
+				"4:2: malformed case header, expected case(title) { or case(title)(link) { [malformed-case]",
+				"10:1: unexpected } [stray-brace]",
+			},
+		},
+		{
+			input: `content {
+	case(hello) {
+		sources {
+			{footnotes}`,
+			want: []string{
+				"3:3: unterminated sources block [unterminated-block]",
+				"2:2: unterminated case(hello) block [unterminated-block]",
+				"1:1: unterminated content block [unterminated-block]",
+			},
+		},
+		{
+			input: `!nlp
+!toc
+content { text hello }`,
+			want: []string{
+				"2:1: warning: unknown directive \"!toc\" [unknown-directive]",
+				"3:16: expected { after text [missing-brace]",
+				"3:16: unknown directive \"hello\" [unknown-directive]",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(strings.NewReader(test.input))
+
+		var diags diag.List
+		if !errors.As(err, &diags) {
+			t.Fatalf("Expected a diag.List, got %v", err)
+		}
+
+		if len(diags) != len(test.want) {
+			t.Errorf("Expected %d diagnostics, got %d:\n%v", len(test.want), len(diags), err)
+			continue
+		}
+		for i, d := range diags {
+			if d.Error() != test.want[i] {
+				t.Errorf("Diagnostic %d: expected %q, got %q", i, test.want[i], d.Error())
+			}
+		}
+	}
+}
+
+func TestParseBraceLayout(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`title: layout
+content {
+	case(x){text { hello {https://example.com}{world} }
+	}
+	case (y)
+	{
+		sources{ {footnotes} }}}`))
+	if err != nil {
+		t.Fatalf("Parse returned %v", err)
+	}
+
+	cases := doc.Cases()
+	if len(cases) != 2 {
+		t.Fatalf("Expected 2 cases, got %d", len(cases))
+	}
+	text, ok := cases[0].Body[0].(*ast.TextNode)
+	if !ok || text.Content != "hello {https://example.com}{world}" {
+		t.Errorf("Unexpected body of case x: %#v", cases[0].Body)
+	}
+	sources, ok := cases[1].Body[0].(*ast.SourcesNode)
+	if !ok || sources.Content != "{footnotes}" {
+		t.Errorf("Unexpected body of case y: %#v", cases[1].Body)
+	}
+}
+
+func TestParseKeepsDocumentOrder(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`content {
+	text { intro }
+	case(a) {
+		text { a1 }
+		case(b) { text { b1 } }
+		text { a2 }
+	}
+	sources { {footnotes} }
+}`))
+	if err != nil {
+		t.Fatalf("Parse returned %v", err)
+	}
+
+	var kinds []string
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		kinds = append(kinds, n.Kind().String())
+		for _, child := range n.Children() {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	got := strings.Join(kinds, " ")
+	want := "document text case text case text text sources"
+	if got != want {
+		t.Errorf("Expected nodes %q, got %q", want, got)
+	}
+}
+
+func TestParsePathLoadsWebographyNextToDocument(t *testing.T) {
+	dir := t.TempDir()
+	docPath := filepath.Join(dir, "doc.nama")
+	if err := os.WriteFile(docPath, []byte("content { text { ${a} } }"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(WebographyPath(docPath), []byte("T: a\nN: A\nD: 2020\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := New(Options{}).ParsePath(docPath)
+	if err != nil {
+		t.Fatalf("ParsePath returned %v", err)
+	}
+	if _, ok := doc.Webography.Lookup("a"); !ok {
+		t.Errorf("Expected the webography next to the document to be loaded")
+	}
+
+	bib := ast.NewWebography()
+	bib.Add(&ast.WBibEntry{Keyword: "b"})
+	doc, err = New(Options{Webography: bib}).ParsePath(docPath)
+	if err != nil {
+		t.Fatalf("ParsePath returned %v", err)
+	}
+	if doc.Webography != bib {
+		t.Errorf("Expected the webography from the options to be used")
+	}
+}
+
+func TestParseWebographyBlock(t *testing.T) {
+	external := ast.NewWebography()
+	external.Add(&ast.WBibEntry{Keyword: "shared", Name: "From the file"})
+	external.Add(&ast.WBibEntry{Keyword: "fileonly", Name: "Only in the file"})
+
+	doc, err := New(Options{Webography: external}).Parse(strings.NewReader(`title: self-contained
+webography {
+	T: shared
+	N: From the document
+
+	T: inline
+	N: Only in the document
+	X: stray
+}
+content {
+	references {
+		T: late
+		N: Declared inside content
+	}
+	text { ${shared} ${inline} ${fileonly} ${late} }
+}`))
+	if err != nil {
+		t.Fatalf("Parse returned %v", err)
+	}
+
+	for keyword, name := range map[string]string{
+		"shared":   "From the document",
+		"inline":   "Only in the document",
+		"fileonly": "Only in the file",
+		"late":     "Declared inside content",
+	} {
+		entry, ok := doc.Webography.Lookup(keyword)
+		if !ok || entry.Name != name {
+			t.Errorf("Expected %q to resolve to %q, got %+v", keyword, name, entry)
+		}
+	}
+
+	if entry, _ := external.Lookup("shared"); entry.Name != "From the file" {
+		t.Errorf("Expected the external webography to be left untouched")
+	}
+	if origin, _ := doc.Webography.Origin("inline"); origin.Line != 6 {
+		t.Errorf("Expected the inline entry to start on line 6, got %d", origin.Line)
+	}
+
+	diags := doc.Webography.Diagnostics()
+	if len(diags) != 1 || diags[0].Line != 8 || diags[0].Code != ast.CodeStrayField {
+		t.Errorf("Expected a stray field on line 8, got %v", diags)
+	}
+}
+
+func TestParseTextParagraphs(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`!nlp
+text(nlp) {
+	First paragraph
+	goes on.
+
+	Second line \
+	breaks here.
+
+
+	Third.
+}
+text(!nlp) { raw }
+text { plain }
+text(loud) { x }
+`))
+
+	var diags diag.List
+	if !errors.As(err, &diags) || len(diags) != 1 || diags[0].Code != CodeUnknownDirective {
+		t.Errorf("Expected one unknown-directive diagnostic, got %v", err)
+	}
+
+	blocks := doc.Content
+	if len(blocks) != 4 {
+		t.Fatalf("Expected 4 blocks, got %d", len(blocks))
+	}
+	want := "First paragraph goes on.\n\nSecond line\nbreaks here.\n\nThird."
+	if got := blocks[0].(*ast.TextNode).Content; got != want {
+		t.Errorf("Expected content %q, got %q", want, got)
+	}
+	for i, paragraphs := range []bool{true, false, false, false} {
+		if got := blocks[i].(*ast.TextNode).UsesParagraphs(doc); got != paragraphs {
+			t.Errorf("Block %d: expected UsesParagraphs %v, got %v", i, paragraphs, got)
+		}
+	}
+}
+
+func TestParseInlineMarkup(t *testing.T) {
+	nodes := ParseInline("a {**b {_c}}\n{`{x}}")
+
+	if len(nodes) != 4 {
+		t.Fatalf("Expected 4 inline nodes, got %d: %#v", len(nodes), nodes)
+	}
+	strong, ok := nodes[1].(*ast.Styled)
+	if !ok || strong.Style != ast.Strong || len(strong.Children) != 2 {
+		t.Fatalf("Expected strong text with two children, got %#v", nodes[1])
+	}
+	if sub, ok := strong.Children[1].(*ast.Styled); !ok || sub.Style != ast.Subscript {
+		t.Errorf("Expected nested subscript, got %#v", strong.Children[1])
+	}
+	if _, ok := nodes[2].(*ast.LineBreak); !ok {
+		t.Errorf("Expected a line break, got %#v", nodes[2])
+	}
+	if code, ok := nodes[3].(*ast.Code); !ok || code.Value != "{x}" {
+		t.Errorf("Expected code {x}, got %#v", nodes[3])
+	}
+}
+
+func TestParseInlineElements(t *testing.T) {
+	nodes := ParseInline("${a; b, p. 2}{https://x.example}{see {*this}} {img/i.png}{alt} {footnotes:case}{nope} ${open")
+
+	var kinds []string
+	for _, n := range nodes {
+		kinds = append(kinds, fmt.Sprintf("%T", n))
+	}
+	want := "*ast.Citation *ast.Link *ast.Text *ast.Image *ast.Text *ast.Footnotes *ast.Text"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Fatalf("Expected %s, got %s", want, got)
+	}
+
+	if c := nodes[0].(*ast.Citation); len(c.Items) != 2 || c.Items[1].Locator != "p. 2" {
+		t.Errorf("Unexpected citation %+v", c)
+	}
+	link := nodes[1].(*ast.Link)
+	if link.URL != "https://x.example" || len(link.Children) != 2 {
+		t.Errorf("Unexpected link %+v", link)
+	}
+	if img := nodes[3].(*ast.Image); img.Src != "i.png" || img.Alt != "alt" {
+		t.Errorf("Unexpected image %+v", img)
+	}
+	if f := nodes[5].(*ast.Footnotes); f.Scope != ast.FootnotesCase {
+		t.Errorf("Expected case footnotes, got %+v", f)
+	}
+	if text := nodes[6].(*ast.Text); text.Value != "{nope} ${open" {
+		t.Errorf("Expected the rest to stay text, got %q", text.Value)
+	}
+}
+
+func TestParseHTMLBlock(t *testing.T) {
+	doc, err := Parse(strings.NewReader("html {\n\t<div>\n\t  {raw}\n\n\t</div>\n}\ntext { {html:<br/>} }"))
+	if err != nil {
+		t.Fatalf("Parse returned %v", err)
+	}
+	if len(doc.Content) != 2 {
+		t.Fatalf("Expected 2 blocks, got %d", len(doc.Content))
+	}
+
+	block, ok := doc.Content[0].(*ast.HTMLNode)
+	if !ok || block.Content != "<div>\n{raw}\n</div>" {
+		t.Errorf("Unexpected html block %#v", doc.Content[0])
+	}
+	text := doc.Content[1].(*ast.TextNode)
+	if raw, ok := text.Paragraphs[0][0].(*ast.RawHTML); !ok || raw.Value != "<br/>" {
+		t.Errorf("Expected inline raw HTML, got %#v", text.Paragraphs[0])
+	}
+}
+
+func TestParseCodeBlock(t *testing.T) {
+	src := "case(x) {\n" +
+		"\tcode(sh) {\n" +
+		"\t\tif true; then\n" +
+		"\t\t  echo }\n" +
+		"\n" +
+		"\t\tfi\n" +
+		"\t}\n" +
+		"\tcode(go) {\n" +
+		"\t\tfunc f() {\n" +
+		"\t\t}\n" +
+		"\t}\n" +
+		"\tcode { x := map[string]int{} }\n" +
+		"\tcode(sh) {\n" +
+		"\t\techo \"{\"\n" +
+		"\t}\n" +
+		"}\n"
+	doc, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("Parse returned %v", err)
+	}
+
+	body := doc.Cases()[0].Body
+	if len(body) != 4 {
+		t.Fatalf("Expected 4 code blocks, got %d", len(body))
+	}
+	for i, want := range []struct {
+		lang, content string
+		end           ast.Position
+	}{
+		{"sh", "if true; then\n  echo }\n\nfi", ast.Position{Line: 7, Column: 2}},
+		{"go", "func f() {\n}", ast.Position{Line: 11, Column: 2}},
+		{"", "x := map[string]int{}", ast.Position{Line: 12, Column: 31}},
+		// Unbalanced braces in the code do not keep the block open.
+		{"sh", `echo "{"`, ast.Position{Line: 15, Column: 2}},
+	} {
+		code := body[i].(*ast.CodeNode)
+		if code.Lang != want.lang || code.Content != want.content || code.End() != want.end {
+			t.Errorf("Block %d: expected %q %q ending at %v, got %q %q ending at %v",
+				i, want.lang, want.content, want.end, code.Lang, code.Content, code.End())
+		}
+	}
+}
+
+func TestParseListBlock(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`olist {
+	First {*item}
+
+	Second
+	list {
+		Nested ${k}
+		olist {
+			Deep
+		}
+	}
+	Third
+}
+list { Only }
+`))
+	if err != nil {
+		t.Fatalf("Parse returned %v", err)
+	}
+	if len(doc.Content) != 2 {
+		t.Fatalf("Expected 2 lists, got %d", len(doc.Content))
+	}
+
+	list := doc.Content[0].(*ast.ListNode)
+	if !list.Ordered || len(list.Items) != 3 || list.End() != (ast.Position{Line: 12, Column: 1}) {
+		t.Fatalf("Unexpected list %+v", list)
+	}
+	if len(list.Items[0].Content) != 2 || list.Items[2].Text != "Third" {
+		t.Errorf("Unexpected items %+v %+v", list.Items[0], list.Items[2])
+	}
+
+	sublists := list.Items[1].Sublists
+	if len(sublists) != 1 || sublists[0].Ordered || sublists[0].Start() != (ast.Position{Line: 5, Column: 2}) {
+		t.Fatalf("Expected a bulleted sublist on line 5, got %+v", sublists)
+	}
+	nested := sublists[0].Items[0]
+	if nested.Text != "Nested ${k}" || len(nested.Sublists) != 1 || !nested.Sublists[0].Ordered {
+		t.Errorf("Unexpected nested item %+v", nested)
+	}
+	if len(list.Children()) != 1 {
+		t.Errorf("Expected the sublist among the children, got %v", list.Children())
+	}
+
+	if only := doc.Content[1].(*ast.ListNode); len(only.Items) != 1 || only.Items[0].Text != "Only" {
+		t.Errorf("Unexpected one-line list %+v", only)
+	}
+}
+
+func TestParseTableBlock(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "data.csv"), []byte("Name,Score\n\"Doe, J.\",3\nRoe\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := New(Options{Dir: dir}).Parse(strings.NewReader(`content {
+table {
+	caption: Scores {*so far}
+	| Name | Score | {https://x.example}{a|b} |
+	|:-----|------:|:-:|
+	| a \| b | 1 |
+}
+table {
+	align: right
+	x	y
+	1		2
+}
+table(data.csv) {
+	caption: From a file
+}
+table(missing.csv) {}
+table {
+	| a |
+	| b |
+	|---|
+}
+}
+`))
+
+	var diags diag.List
+	if !errors.As(err, &diags) || len(diags) != 2 ||
+		diags[0].Code != CodeUnreadableFile || diags[1].Code != CodeBadTable || diags[1].Line != 20 {
+		t.Errorf("Expected an unreadable-file and a bad-table diagnostic, got %v", err)
+	}
+	if len(doc.Content) != 5 {
+		t.Fatalf("Expected 5 tables, got %d", len(doc.Content))
+	}
+
+	piped := doc.Content[0].(*ast.TableNode)
+	if len(piped.Caption) != 2 || len(piped.Header) != 3 || piped.Header[2].Text != "{https://x.example}{a|b}" {
+		t.Errorf("Unexpected caption or header %+v %+v", piped.Caption, piped.Header)
+	}
+	wantAlign := []ast.Alignment{ast.AlignLeft, ast.AlignRight, ast.AlignCenter}
+	if fmt.Sprint(piped.Align) != fmt.Sprint(wantAlign) {
+		t.Errorf("Expected alignments %v, got %v", wantAlign, piped.Align)
+	}
+	if len(piped.Rows) != 1 || piped.Rows[0][0].Text != "a | b" || piped.Columns() != 3 {
+		t.Errorf("Unexpected rows %+v", piped.Rows)
+	}
+
+	tabbed := doc.Content[1].(*ast.TableNode)
+	if tabbed.Header != nil || len(tabbed.Rows) != 2 || tabbed.Rows[1][1].Text != "2" || tabbed.Align[0] != ast.AlignRight {
+		t.Errorf("Unexpected tab-separated table %+v", tabbed)
+	}
+
+	csvTable := doc.Content[2].(*ast.TableNode)
+	if csvTable.Source != "data.csv" || len(csvTable.Header) != 2 || len(csvTable.Rows) != 2 ||
+		csvTable.Rows[0][0].Text != "Doe, J." || len(csvTable.Rows[1]) != 1 {
+		t.Errorf("Unexpected CSV table %+v", csvTable)
+	}
+}
+
+func TestParseQuoteAndCalloutBlocks(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`quote(${+knuth, p. 3}) {
+	Premature optimization \
+	is the root of all evil.
+
+	Second paragraph.
+}
+quote {
+	Unattributed.
+}
+warning(Mind {*the} gap) {
+	Text
+}
+aside {
+	By the way.
+}
+`))
+	if err != nil {
+		t.Fatalf("Parse returned %v", err)
+	}
+	if len(doc.Content) != 4 {
+		t.Fatalf("Expected 4 blocks, got %d", len(doc.Content))
+	}
+
+	quote := doc.Content[0].(*ast.QuoteNode)
+	if quote.Content != "Premature optimization\nis the root of all evil.\n\nSecond paragraph." || len(quote.Paragraphs) != 2 {
+		t.Errorf("Unexpected quote content %q", quote.Content)
+	}
+	if c, ok := quote.Attribution[0].(*ast.Citation); len(quote.Attribution) != 1 || !ok ||
+		c.Mode != ast.CiteNarrative || c.Items[0].Keyword != "knuth" {
+		t.Errorf("Expected the attribution to cite knuth, got %+v", quote.Attribution)
+	}
+	if plain := doc.Content[1].(*ast.QuoteNode); len(plain.Attribution) != 0 {
+		t.Errorf("Expected no attribution, got %+v", plain.Attribution)
+	}
+
+	warning := doc.Content[2].(*ast.CalloutNode)
+	if warning.Type != ast.CalloutWarning || len(warning.Title) != 3 || warning.Content != "Text" {
+		t.Errorf("Unexpected warning %+v", warning)
+	}
+	if aside := doc.Content[3].(*ast.CalloutNode); aside.Type != ast.CalloutAside || aside.Title != nil {
+		t.Errorf("Unexpected aside %+v", aside)
+	}
+}
+
+func TestParseFigureBlock(t *testing.T) {
+	doc, err := Parse(strings.NewReader(`content {
+	text {
+		As {ref:arch} shows
+	}
+	figure(arch) {
+		caption: The {*overall} design
+		{img/arch.png}{Boxes and arrows}
+	}
+	figure {
+		table {
+			| a | b |
+		}
+	}
+	figure(arch) {}
+	figure(two words) {}
+}
+`))
+
+	var diags diag.List
+	if !errors.As(err, &diags) || len(diags) != 2 ||
+		diags[0].Code != CodeBadFigure || diags[0].Line != 14 || diags[1].Code != CodeBadFigure {
+		t.Errorf("Expected two bad-figure diagnostics, got %v", err)
+	}
+	if len(doc.Content) != 5 {
+		t.Fatalf("Expected 5 blocks, got %d", len(doc.Content))
+	}
+
+	text := doc.Content[0].(*ast.TextNode)
+	if ref, ok := text.Paragraphs[0][1].(*ast.Ref); !ok || ref.ID != "arch" {
+		t.Errorf("Expected a reference to arch, got %+v", text.Paragraphs[0])
+	}
+
+	arch := doc.Content[1].(*ast.FigureNode)
+	if arch.ID != "arch" || arch.Number != 1 || len(arch.Caption) != 3 || arch.Body != nil {
+		t.Errorf("Unexpected figure %+v", arch)
+	}
+	if img, ok := arch.Content[0].(*ast.Image); len(arch.Content) != 1 || !ok || img.Src != "arch.png" {
+		t.Errorf("Expected an image, got %+v", arch.Content)
+	}
+
+	table := doc.Content[2].(*ast.FigureNode)
+	if table.Number != 2 || len(table.Body) != 1 || table.Body[0].Kind() != ast.KindTable || table.Content != nil {
+		t.Errorf("Unexpected figure %+v", table)
+	}
+	if figures := ast.Figures(doc); len(figures) != 1 || figures["arch"] != arch {
+		t.Errorf("Expected only the first arch figure to be found, got %v", figures)
+	}
+}
+
+func TestParseCodeBlockFlushLeft(t *testing.T) {
+	_, err := Parse(strings.NewReader("content {\n" +
+		"\tcode(go) {\n" +
+		"func f() {\n" +
+		"}\n" +
+		"\t}\n" +
+		"}\n"))
+
+	var diags diag.List
+	if !errors.As(err, &diags) || len(diags) == 0 {
+		t.Fatalf("Expected diagnostics, got %v", err)
+	}
+	if d := diags[0]; d.Code != CodeCodeIndent || d.Line != 2 || !strings.Contains(d.Message, "line 3") {
+		t.Errorf("Expected a code-indent diagnostic at the code block naming line 3, got %v", d)
 	}
 }
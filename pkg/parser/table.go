@@ -0,0 +1,237 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package parser
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+)
+
+// parseTableBlock reads a table block. Its body holds rows, one per line,
+// with cells separated by | or tabs, and settings:
+//
+//	caption: text shown with the table
+//	align:   one of left, center or right per column
+//	header:  yes or no, whether the first row is a header
+//
+// A row of dashes such as |:--|:-:|--:| after the first row makes that row
+// the header and sets the alignments, as in Markdown. table(file.csv) reads
+// the rows from a CSV file next to the document instead; its first row is
+// the header unless the body says header: no.
+func (p *parser) parseTableBlock(tok token) *ast.TableNode {
+	table := &ast.TableNode{Span: ast.Span{From: tok.pos}}
+	if p.lex.peekParen() {
+		source, _ := p.lex.parenArg()
+		table.Source = strings.TrimSpace(source)
+	}
+	if !p.expectBrace(tok) {
+		table.To = p.last
+		return table
+	}
+	firstLine := p.last.Line
+
+	body, end := p.parseLeafBody(tok)
+	table.To = end
+
+	header := table.Source != ""
+	var rows [][]string
+	for i, line := range strings.Split(body, "\n") {
+		text := strings.TrimSpace(line)
+		pos := ast.Position{Line: firstLine + i, Column: len(line) - len(strings.TrimLeft(line, " \t")) + 1}
+		if text == "" {
+			continue
+		}
+
+		if key, value, ok := tableSetting(text); ok {
+			switch key {
+			case "caption":
+				table.Caption = ParseInline(value)
+			case "align":
+				if table.Align, ok = parseAlignments(value); !ok {
+					p.errorf(pos, CodeBadTable, "bad alignment %q, expected left, center or right per column", value)
+				}
+			case "header":
+				if value != "yes" && value != "no" {
+					p.errorf(pos, CodeBadTable, "bad header setting %q, expected yes or no", value)
+				}
+				header = value == "yes"
+			}
+			continue
+		}
+
+		if table.Source != "" {
+			p.errorf(pos, CodeBadTable, "the rows of table(%s) come from the file, not the block", table.Source)
+			continue
+		}
+		cells := splitRow(text)
+		if align, ok := separatorRow(cells); ok {
+			if len(rows) != 1 {
+				p.errorf(pos, CodeBadTable, "a separator row must follow the header row")
+				continue
+			}
+			header = true
+			if table.Align == nil {
+				table.Align = align
+			}
+			continue
+		}
+		rows = append(rows, cells)
+	}
+
+	if table.Source != "" {
+		rows = p.readCSV(tok, table.Source)
+	}
+	if header && len(rows) > 0 {
+		table.Header = tableCells(rows[0])
+		rows = rows[1:]
+	}
+	for _, row := range rows {
+		table.Rows = append(table.Rows, tableCells(row))
+	}
+
+	return table
+}
+
+// readCSV reads the rows of a table from the CSV file at path, relative to
+// the document.
+func (p *parser) readCSV(tok token, path string) [][]string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.dir, path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		p.errorf(tok.pos, CodeUnreadableFile, "cannot read table: %v", err)
+		return nil
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		p.errorf(tok.pos, CodeUnreadableFile, "cannot read table %s: %v", path, err)
+	}
+	return rows
+}
+
+func tableSetting(line string) (key, value string, ok bool) {
+	key, value, ok = strings.Cut(line, ":")
+	switch key {
+	case "caption", "align", "header":
+		return key, strings.TrimSpace(value), ok
+	}
+	return "", "", false
+}
+
+func parseAlignments(value string) ([]ast.Alignment, bool) {
+	var align []ast.Alignment
+	for _, name := range strings.Fields(value) {
+		switch name {
+		case "left", "l":
+			align = append(align, ast.AlignLeft)
+		case "center", "c":
+			align = append(align, ast.AlignCenter)
+		case "right", "r":
+			align = append(align, ast.AlignRight)
+		case "-":
+			align = append(align, ast.AlignDefault)
+		default:
+			return align, false
+		}
+	}
+	return align, true
+}
+
+// splitRow splits a table row into cells. Rows holding a | outside braces
+// are split on it, ignoring a leading and trailing |; \| stands for a |
+// inside a cell. Other rows are split on runs of tabs.
+func splitRow(line string) []string {
+	var cells []string
+	var cell strings.Builder
+	depth, piped := 0, false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case c == '|' && depth == 0:
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+			piped = true
+		default:
+			if c == '{' {
+				depth++
+			} else if c == '}' && depth > 0 {
+				depth--
+			}
+			cell.WriteByte(c)
+		}
+	}
+	cells = append(cells, strings.TrimSpace(cell.String()))
+
+	if !piped {
+		return strings.FieldsFunc(line, func(c rune) bool { return c == '\t' })
+	}
+	if strings.HasPrefix(line, "|") {
+		cells = cells[1:]
+	}
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		cells = cells[:len(cells)-1]
+	}
+	return cells
+}
+
+// separatorRow reports whether cells make up a row of dashes such as
+// :--, :-: and --:, and returns the alignments they set.
+func separatorRow(cells []string) ([]ast.Alignment, bool) {
+	if len(cells) == 0 {
+		return nil, false
+	}
+	align := make([]ast.Alignment, len(cells))
+	for i, cell := range cells {
+		dashes := strings.Trim(cell, ":")
+		if dashes == "" || strings.Trim(dashes, "-") != "" {
+			return nil, false
+		}
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			align[i] = ast.AlignCenter
+		case left:
+			align[i] = ast.AlignLeft
+		case right:
+			align[i] = ast.AlignRight
+		}
+	}
+	return align, true
+}
+
+func tableCells(row []string) []ast.TableCell {
+	cells := make([]ast.TableCell, len(row))
+	for i, text := range row {
+		text = strings.TrimSpace(text)
+		cells[i] = ast.TableCell{Text: text, Content: ParseInline(text)}
+	}
+	return cells
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package renderer
+
+import (
+	"github.com/nanomarkdown/nanami/pkg/ast"
+	"github.com/nanomarkdown/nanami/pkg/diag"
+)
+
+// CodeRawHTML is reported by Check for raw HTML that will be escaped.
+const CodeRawHTML = "raw-html"
+
+// Check reports parts of doc that r will not render as written: unless raw
+// HTML is allowed, html { ... } blocks and {html:...} elements are escaped.
+func (r *HTML) Check(doc *ast.Document) diag.List {
+	var diags diag.List
+	if r.AllowRawHTML || r.Unsafe {
+		return diags
+	}
+
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		pos := n.Start()
+		if _, ok := n.(*ast.HTMLNode); ok {
+			diags.Warnf(pos.Line, pos.Column, CodeRawHTML,
+				"html block is escaped; render with -raw-html to keep it")
+		}
+		ast.WalkInline(ast.InlineContent(n), func(in ast.Inline) {
+			if _, ok := in.(*ast.RawHTML); ok {
+				diags.Warnf(pos.Line, pos.Column, CodeRawHTML,
+					"{html:...} is escaped; render with -raw-html to keep it")
+			}
+		})
+		for _, child := range n.Children() {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return diags
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package renderer
+
+import (
+	"fmt"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+)
+
+// citations numbers webography entries in the order they are first cited
+// while a document is being rendered, and remembers where each was cited so
+// the bibliography can link back.
+//
+// Numbering normally runs across the whole document. A top-level case that
+// asks for {footnotes:case} gets a scope of its own, numbered from 1; its
+// citations still count towards the document scope so that {footnotes:all}
+// lists everything.
+type citations struct {
+	bib      *ast.Webography
+	document *citeScope
+	current  *citeScope
+}
+
+// citeScope is one numbering of cited entries. prefix keeps the anchors of
+// different scopes apart.
+type citeScope struct {
+	prefix  string
+	ordered []*ast.WBibEntry
+	numbers map[string]int
+	sites   map[int][]string
+	anchors map[int]int
+}
+
+func newCitations(bib *ast.Webography) *citations {
+	document := newCiteScope("")
+	return &citations{bib: bib, document: document, current: document}
+}
+
+func newCiteScope(prefix string) *citeScope {
+	return &citeScope{prefix: prefix, numbers: map[string]int{}, sites: map[int][]string{}, anchors: map[int]int{}}
+}
+
+// enterCase starts the i-th top-level case, which numbers its citations on
+// its own when scoped is set.
+func (c *citations) enterCase(i int, scoped bool) {
+	if scoped {
+		c.current = newCiteScope(fmt.Sprintf("c%d-", i))
+	}
+}
+
+// leaveCase returns to the document scope.
+func (c *citations) leaveCase() {
+	c.current = c.document
+}
+
+// cite returns the number assigned to keyword in the current scope,
+// assigning the next free one on first use. It reports false for keywords
+// missing from the webography.
+func (c *citations) cite(keyword string) (int, bool) {
+	if c.bib == nil {
+		return 0, false
+	}
+	entry, exists := c.bib.Lookup(keyword)
+	if !exists {
+		return 0, false
+	}
+
+	if c.current != c.document {
+		c.document.number(entry)
+	}
+	return c.current.number(entry), true
+}
+
+// entry returns the entry numbered n in the current scope.
+func (c *citations) entry(n int) *ast.WBibEntry {
+	return c.current.ordered[n-1]
+}
+
+// anchor returns the id of the bibliography item of the entry numbered n
+// in the current scope.
+func (c *citations) anchor(n int) string {
+	return c.current.anchor(n)
+}
+
+// site returns a fresh anchor id for a citation of the entry numbered n and
+// records it as one of the places the entry was cited.
+func (c *citations) site(n int) string {
+	s := c.current
+	s.anchors[n]++
+	id := fmt.Sprintf("%scite-%d-%d", s.prefix, n, s.anchors[n])
+	c.citedAt(n, id)
+	return id
+}
+
+// citedAt records that the entry numbered n was cited at the anchor id,
+// for citations that share the anchor of another entry.
+func (c *citations) citedAt(n int, id string) {
+	c.current.sites[n] = append(c.current.sites[n], id)
+	if c.current != c.document {
+		keyword := c.current.ordered[n-1].Keyword
+		docN := c.document.numbers[keyword]
+		c.document.sites[docN] = append(c.document.sites[docN], id)
+	}
+}
+
+func (s *citeScope) number(entry *ast.WBibEntry) int {
+	if n, exists := s.numbers[entry.Keyword]; exists {
+		return n
+	}
+	s.ordered = append(s.ordered, entry)
+	s.numbers[entry.Keyword] = len(s.ordered)
+	return len(s.ordered)
+}
+
+func (s *citeScope) anchor(n int) string {
+	return fmt.Sprintf("%ss%d", s.prefix, n)
+}
+
+// hasCaseFootnotes reports whether n or anything below it asks for
+// {footnotes:case}.
+func hasCaseFootnotes(n ast.Node) bool {
+	found := false
+	ast.WalkInline(ast.InlineContent(n), func(in ast.Inline) {
+		if footnotes, ok := in.(*ast.Footnotes); ok && footnotes.Scope == ast.FootnotesCase {
+			found = true
+		}
+	})
+	if found {
+		return true
+	}
+	for _, child := range n.Children() {
+		if hasCaseFootnotes(child) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package renderer
+
+import (
+	"net/url"
+	"strings"
+)
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&#39;")
+)
+
+// escape makes s safe to write as element content.
+func (h *htmlWriter) escape(s string) string {
+	if h.unsafe {
+		return s
+	}
+	return textEscaper.Replace(s)
+}
+
+// raw writes raw HTML as it is when it is allowed and escapes it otherwise.
+func (h *htmlWriter) raw(s string) string {
+	if h.allowRaw {
+		return s
+	}
+	return textEscaper.Replace(s)
+}
+
+// attr makes s safe to write as a quoted attribute value.
+func (h *htmlWriter) attr(s string) string {
+	if h.unsafe {
+		return s
+	}
+	return attrEscaper.Replace(s)
+}
+
+// url makes s safe to write as a link or image attribute. Besides being
+// escaped, URLs with a scheme other than http, https or mailto are dropped
+// so that javascript: links cannot run.
+func (h *htmlWriter) url(s string) string {
+	if h.unsafe {
+		return s
+	}
+	if u, err := url.Parse(strings.TrimSpace(s)); err != nil ||
+		(u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "mailto") {
+		return "#"
+	}
+	return attrEscaper.Replace(s)
+}
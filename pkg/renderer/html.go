@@ -16,93 +16,317 @@ You should have received a copy of the GNU General Public License
 along with nanami. If not, see <https://www.gnu.org/licenses/>.
 */
 
-package parser
+package renderer
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"strings"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+	"github.com/nanomarkdown/nanami/pkg/cite"
+	"github.com/nanomarkdown/nanami/pkg/highlight"
 )
 
-func writeIndent(w io.Writer, level int, s string) {
+// HTML renders documents as standalone HTML pages. Text and attributes are
+// escaped, and so are html { ... } blocks and {html:...} elements unless
+// AllowRawHTML or Unsafe is set, so that an untrusted document cannot add
+// markup of its own.
+type HTML struct {
+	// Style formats citations. When nil, the built-in style named by the
+	// document's style: header is used, or cite.Default.
+	Style *cite.Style
+	// Unsafe writes raw HTML, text, titles, URLs and webography fields as
+	// they are, so that documents can hold HTML anywhere. Only use it for
+	// trusted documents.
+	Unsafe bool
+	// AllowRawHTML writes html { ... } blocks and {html:...} elements as
+	// they are while everything else is still escaped, for trusted
+	// documents that need the odd embed.
+	AllowRawHTML bool
+}
+
+func NewHTML() *HTML {
+	return &HTML{}
+}
+
+func (r *HTML) Render(w io.Writer, doc *ast.Document) error {
+	style := r.Style
+	if style == nil {
+		style = cite.Builtin(doc.Style)
+	}
+
+	h := &htmlWriter{
+		w:        bufio.NewWriter(w),
+		doc:      doc,
+		style:    style,
+		cites:    newCitations(doc.Webography),
+		figures:  ast.Figures(doc),
+		unsafe:   r.Unsafe,
+		allowRaw: r.AllowRawHTML || r.Unsafe,
+	}
+	h.document(doc, 0)
+	return h.w.Flush()
+}
+
+// htmlWriter holds the state of a single HTML render.
+type htmlWriter struct {
+	w     *bufio.Writer
+	doc   *ast.Document
+	style *cite.Style
+	cites *citations
+	// figures are the figures {ref:id} can refer to.
+	figures map[string]*ast.FigureNode
+	unsafe  bool
+	// allowRaw writes raw HTML as it is.
+	allowRaw bool
+}
+
+func (h *htmlWriter) writeIndent(level int, s string) {
 	indent := strings.Repeat("  ", level)
-	fmt.Fprint(w, indent, s, "\n")
+	fmt.Fprint(h.w, indent, s, "\n")
+}
+
+func (h *htmlWriter) node(n ast.Node, indent int) {
+	switch n := n.(type) {
+	case *ast.TextNode:
+		h.text(n, indent)
+	case *ast.SourcesNode:
+		h.sources(n, indent)
+	case *ast.HTMLNode:
+		h.rawHTML(n, indent)
+	case *ast.CodeNode:
+		h.code(n, indent)
+	case *ast.FigureNode:
+		h.figure(n, indent)
+	case *ast.QuoteNode:
+		h.quote(n, indent)
+	case *ast.CalloutNode:
+		h.callout(n, indent)
+	case *ast.ListNode:
+		h.list(n, indent)
+	case *ast.TableNode:
+		h.table(n, indent)
+	case *ast.CaseNode:
+		h.caseNode(n, indent)
+	}
+}
+
+func (h *htmlWriter) document(d *ast.Document, indent int) {
+	h.writeIndent(indent, "<html>")
+	h.writeIndent(indent+1, "<head>")
+	h.writeIndent(indent+2, "<title>"+h.escape(d.Title)+"</title>")
+	h.writeIndent(indent+1, "</head>")
+	h.writeIndent(indent+1, "<body>")
+	cases := 0
+	for _, n := range d.Content {
+		if c, ok := n.(*ast.CaseNode); ok {
+			cases++
+			h.cites.enterCase(cases, hasCaseFootnotes(c))
+		}
+		h.node(n, indent+2)
+		h.cites.leaveCase()
+	}
+	h.writeIndent(indent+1, "</body>")
+	h.writeIndent(indent, "</html>")
 }
 
-func (c *CaseNode) Render(w io.Writer, indent int) {
-	writeIndent(w, indent, `<div class="case">`)
+func (h *htmlWriter) caseNode(c *ast.CaseNode, indent int) {
+	h.writeIndent(indent, `<div class="case">`)
 
 	id := strings.ReplaceAll(strings.TrimSpace(c.Title), " ", "_")
 
 	if c.Link != "" {
 		titleTag := fmt.Sprintf(`<h4 id="%s"><a href="%s">%s</a></h4>`,
-			id,
-			c.Link,
-			c.Title)
-		writeIndent(w, indent+1, titleTag)
+			h.attr(id),
+			h.url(c.Link),
+			h.escape(c.Title))
+		h.writeIndent(indent+1, titleTag)
 	} else {
 		titleTag := fmt.Sprintf(`<h4 id="%s">%s</h4>`,
-			id,
-			c.Title)
-		writeIndent(w, indent+1, titleTag)
+			h.attr(id),
+			h.escape(c.Title))
+		h.writeIndent(indent+1, titleTag)
 	}
 
 	for _, n := range c.Body {
-		n.Render(w, indent+1)
+		h.node(n, indent+1)
 	}
 
-	for _, subCase := range c.SubCases {
-		subCase.Render(w, indent+1)
+	h.writeIndent(indent, "</div>")
+}
+
+func (h *htmlWriter) text(tb *ast.TextNode, indent int) {
+	h.writeIndent(indent, `<div class="text-block">`)
+
+	for _, paragraph := range tb.Paragraphs {
+		content := strings.TrimSpace(h.inlines(paragraph))
+		if content == "" {
+			continue
+		}
+		if tb.UsesParagraphs(h.doc) {
+			content = "<p>" + content + "</p>"
+		}
+		h.writeIndent(indent+1, content)
 	}
 
-	writeIndent(w, indent, "</div>")
+	h.writeIndent(indent, "</div>")
 }
 
-func (d *Document) Render(w io.Writer, indent int) {
-	writeIndent(w, indent, "<html>")
-	writeIndent(w, indent+1, "<head>")
-	writeIndent(w, indent+2, "<title>"+d.Title+"</title>")
-	writeIndent(w, indent+1, "</head>")
-	writeIndent(w, indent+1, "<body>")
-	for _, n := range d.Content {
-		n.Render(w, indent+2)
+// figure writes a figure: its lines of inline content, its blocks and a
+// numbered caption.
+func (h *htmlWriter) figure(f *ast.FigureNode, indent int) {
+	h.writeIndent(indent, fmt.Sprintf(`<figure id="%s">`, h.attr(figureAnchor(f))))
+	if content := strings.TrimSpace(h.inlines(f.Content)); content != "" {
+		h.writeIndent(indent+1, content)
 	}
-	for _, c := range d.Cases {
-		c.Render(w, indent+2)
+	for _, n := range f.Body {
+		h.node(n, indent+1)
+	}
+
+	caption := fmt.Sprintf("Figure %d", f.Number)
+	if text := strings.TrimSpace(h.inlines(f.Caption)); text != "" {
+		caption += ": " + text
 	}
-	writeIndent(w, indent+1, "</body>")
-	writeIndent(w, indent, "</html>")
+	h.writeIndent(indent+1, "<figcaption>"+caption+"</figcaption>")
+	h.writeIndent(indent, "</figure>")
 }
 
-func (tb *TextBlockNode) Render(w io.Writer, indent int) {
-	writeIndent(w, indent, `<div class="text-block">`)
+func figureAnchor(f *ast.FigureNode) string {
+	if f.ID != "" {
+		return "figure-" + f.ID
+	}
+	return fmt.Sprintf("figure-%d", f.Number)
+}
 
-	processor := &InlineProcessor{}
-	content := processor.ProcessInlineElements(tb.Content)
-	content = strings.TrimSpace(content)
+// quote writes a block quotation, followed by its attribution when it has
+// one.
+func (h *htmlWriter) quote(q *ast.QuoteNode, indent int) {
+	h.writeIndent(indent, "<blockquote>")
+	h.paragraphs(q.Paragraphs, indent+1)
+	if attribution := strings.TrimSpace(h.inlines(q.Attribution)); attribution != "" {
+		h.writeIndent(indent+1, "<footer>— "+attribution+"</footer>")
+	}
+	h.writeIndent(indent, "</blockquote>")
+}
 
-	if tb.NoNLP {
-		if content != "" {
-			writeIndent(w, indent+1, content)
+func (h *htmlWriter) callout(c *ast.CalloutNode, indent int) {
+	h.writeIndent(indent, fmt.Sprintf(`<aside class="%s">`, c.Type))
+	if title := strings.TrimSpace(h.inlines(c.Title)); title != "" {
+		h.writeIndent(indent+1, `<p class="callout-title">`+title+"</p>")
+	}
+	h.paragraphs(c.Paragraphs, indent+1)
+	h.writeIndent(indent, "</aside>")
+}
+
+// paragraphs writes paragraphs of quotes and callouts, which are always
+// marked up, whether the document uses !nlp or not.
+func (h *htmlWriter) paragraphs(paragraphs []ast.Paragraph, indent int) {
+	for _, paragraph := range paragraphs {
+		if content := strings.TrimSpace(h.inlines(paragraph)); content != "" {
+			h.writeIndent(indent, "<p>"+content+"</p>")
 		}
-	} else {
-		if content != "" {
-			writeIndent(w, indent+1, "<p>"+content+"</p>")
+	}
+}
+
+func (h *htmlWriter) sources(s *ast.SourcesNode, indent int) {
+	h.writeIndent(indent, `<div class="sources">`)
+
+	content := strings.TrimSpace(h.inlines(s.Inline))
+
+	if content != "" {
+		h.writeIndent(indent+1, content)
+	}
+	h.writeIndent(indent, "</div>")
+}
+
+// rawHTML writes an html block. Unless raw HTML is allowed its content is
+// escaped like any other text, so that it shows up as written but cannot
+// run.
+func (h *htmlWriter) rawHTML(n *ast.HTMLNode, indent int) {
+	for _, line := range strings.Split(n.Content, "\n") {
+		if line != "" {
+			h.writeIndent(indent, h.raw(line))
 		}
 	}
+}
 
-	writeIndent(w, indent, "</div>")
+// code writes a code block, highlighted when its language is known. The
+// content follows <pre> directly so that no indentation creeps into it.
+func (h *htmlWriter) code(c *ast.CodeNode, indent int) {
+	var b strings.Builder
+	if c.Lang != "" {
+		fmt.Fprintf(&b, `<pre><code class="language-%s">`, attrEscaper.Replace(c.Lang))
+	} else {
+		b.WriteString("<pre><code>")
+	}
+	for _, tok := range highlight.Tokens(c.Lang, c.Content) {
+		text := textEscaper.Replace(tok.Text)
+		if tok.Class != highlight.Plain {
+			text = fmt.Sprintf(`<span class="hl-%s">%s</span>`, tok.Class, text)
+		}
+		b.WriteString(text)
+	}
+	b.WriteString("</code></pre>")
+	h.writeIndent(indent, b.String())
 }
 
-func (s *SourcesNode) Render(w io.Writer, indent int) {
-	writeIndent(w, indent, `<div class="sources">`)
+func (h *htmlWriter) list(l *ast.ListNode, indent int) {
+	tag := "ul"
+	if l.Ordered {
+		tag = "ol"
+	}
 
-	processor := &InlineProcessor{}
-	content := processor.ProcessInlineElements(s.Content)
-	content = strings.TrimSpace(content)
+	h.writeIndent(indent, "<"+tag+">")
+	for _, item := range l.Items {
+		content := h.inlines(item.Content)
+		if len(item.Sublists) == 0 {
+			h.writeIndent(indent+1, "<li>"+content+"</li>")
+			continue
+		}
+		h.writeIndent(indent+1, "<li>"+content)
+		for _, sublist := range item.Sublists {
+			h.list(sublist, indent+2)
+		}
+		h.writeIndent(indent+1, "</li>")
+	}
+	h.writeIndent(indent, "</"+tag+">")
+}
 
-	if content != "" {
-		writeIndent(w, indent+1, content)
+func (h *htmlWriter) table(t *ast.TableNode, indent int) {
+	h.writeIndent(indent, "<table>")
+	if len(t.Caption) > 0 {
+		h.writeIndent(indent+1, "<caption>"+h.inlines(t.Caption)+"</caption>")
+	}
+	if t.Header != nil {
+		h.writeIndent(indent+1, "<thead>")
+		h.tableRow(t, t.Header, "th", indent+2)
+		h.writeIndent(indent+1, "</thead>")
+	}
+	h.writeIndent(indent+1, "<tbody>")
+	for _, row := range t.Rows {
+		h.tableRow(t, row, "td", indent+2)
+	}
+	h.writeIndent(indent+1, "</tbody>")
+	h.writeIndent(indent, "</table>")
+}
+
+// tableRow writes a row of t, padding it to the full width of the table.
+func (h *htmlWriter) tableRow(t *ast.TableNode, row []ast.TableCell, tag string, indent int) {
+	var b strings.Builder
+	b.WriteString("<tr>")
+	for i := range t.Columns() {
+		b.WriteString("<" + tag)
+		if i < len(t.Align) && t.Align[i] != ast.AlignDefault {
+			fmt.Fprintf(&b, ` style="text-align: %s"`, t.Align[i])
+		}
+		b.WriteString(">")
+		if i < len(row) {
+			b.WriteString(h.inlines(row[i].Content))
+		}
+		b.WriteString("</" + tag + ">")
 	}
-	writeIndent(w, indent, "</div>")
+	b.WriteString("</tr>")
+	h.writeIndent(indent, b.String())
 }
@@ -0,0 +1,535 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+	"github.com/nanomarkdown/nanami/pkg/parser"
+)
+
+func textNode(content string) *ast.TextNode {
+	return &ast.TextNode{Content: content, Paragraphs: parser.ParseParagraphs(content)}
+}
+
+func sourcesNode(content string) *ast.SourcesNode {
+	return &ast.SourcesNode{Content: content, Inline: parser.ParseInline(content)}
+}
+
+func TestHTMLRenderCitations(t *testing.T) {
+	bib := ast.NewWebography()
+	bib.Add(&ast.WBibEntry{Keyword: "a", URL: "https://a.example", Name: "A", Date: "2019"})
+	bib.Add(&ast.WBibEntry{Keyword: "b", Name: "B", Date: "2020"})
+
+	doc := &ast.Document{
+		Title: "t",
+		Content: []ast.Node{&ast.CaseNode{
+			Title: "some case",
+			Body: []ast.Node{
+				textNode("x${b} y${a} z${b}"),
+				sourcesNode("{footnotes}"),
+			},
+		}},
+		Webography: bib,
+	}
+
+	var out strings.Builder
+	if err := NewHTML().Render(&out, doc); err != nil {
+		t.Fatalf("Render returned %v", err)
+	}
+	got := out.String()
+
+	for _, want := range []string{
+		`<h4 id="some_case">some case</h4>`,
+		`<p>x<sup><a id="cite-1-1" href="#s1">[1]</a></sup> y<sup><a id="cite-2-1" href="#s2">[2]</a></sup> z<sup><a id="cite-1-2" href="#s1">[1]</a></sup></p>`,
+		`<li id="s1">1. B, 2020 <span class="backrefs">↩ <a href="#cite-1-1">a</a> <a href="#cite-1-2">b</a></span></li>`,
+		`<li id="s2">2. A, 2019 <a href="https://a.example">https://a.example</a> <a class="backref" href="#cite-2-1">↩</a></li>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestHTMLRenderDocumentStyle(t *testing.T) {
+	bib := ast.NewWebography()
+	bib.Add(&ast.WBibEntry{Keyword: "z", Name: "Zeta", Date: "2021", Authors: []string{"Zed, Zoe"}})
+	bib.Add(&ast.WBibEntry{Keyword: "a", Name: "Alpha", Date: "2019", Authors: []string{"Ant, Adam"}})
+
+	doc := &ast.Document{
+		Style: "apa",
+		Content: []ast.Node{
+			textNode("${z}${a}"),
+			sourcesNode("{footnotes}"),
+		},
+		Webography: bib,
+	}
+
+	var out strings.Builder
+	if err := NewHTML().Render(&out, doc); err != nil {
+		t.Fatalf("Render returned %v", err)
+	}
+	got := out.String()
+
+	want := `<p><a id="cite-1-1" href="#s1">(Zed, 2021)</a><a id="cite-2-1" href="#s2">(Ant, 2019)</a></p>`
+	if !strings.Contains(got, want) {
+		t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+	}
+	want = "<ul>\n" +
+		`<li id="s2">Ant, Adam (2019). Alpha. <a class="backref" href="#cite-2-1">↩</a></li>` + "\n" +
+		`<li id="s1">Zed, Zoe (2021). Zeta. <a class="backref" href="#cite-1-1">↩</a></li>` + "\n</ul>"
+	if !strings.Contains(got, want) {
+		t.Errorf("Expected an author-ordered bibliography %q, got:\n%s", want, got)
+	}
+}
+
+func TestHTMLRenderGroupedCitations(t *testing.T) {
+	bib := ast.NewWebography()
+	for _, keyword := range []string{"a", "b", "c", "d"} {
+		bib.Add(&ast.WBibEntry{Keyword: keyword, Name: keyword, Date: "2020"})
+	}
+
+	doc := &ast.Document{
+		Content: []ast.Node{
+			textNode("${d}${a; b; c}${c; d, p. 2}${nope; a, p. 12}${nope}."),
+		},
+		Webography: bib,
+	}
+
+	var out strings.Builder
+	if err := NewHTML().Render(&out, doc); err != nil {
+		t.Fatalf("Render returned %v", err)
+	}
+
+	got := out.String()
+
+	want := `<p><sup><a id="cite-1-1" href="#s1">[1]</a></sup>` +
+		`<sup>[<a id="cite-2-1" href="#s2">2</a>–<a id="cite-4-1" href="#s4">4</a>]</sup>` +
+		`<sup>[<a id="cite-4-2" href="#s4">4</a>, <a id="cite-1-2" href="#s1">1, p. 2</a>]</sup>` +
+		`<sup><a id="cite-2-2" href="#s2">[2, p. 12]</a></sup>.</p>`
+	if !strings.Contains(got, want) {
+		t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+	}
+}
+
+func TestHTMLRenderBackReferences(t *testing.T) {
+	bib := ast.NewWebography()
+	for _, keyword := range []string{"a", "b", "c"} {
+		bib.Add(&ast.WBibEntry{Keyword: keyword, Name: keyword, Date: "2020"})
+	}
+
+	doc := &ast.Document{
+		Content: []ast.Node{
+			textNode("${a; b; c}${b}"),
+			sourcesNode("{footnotes}"),
+		},
+		Webography: bib,
+	}
+
+	var out strings.Builder
+	if err := NewHTML().Render(&out, doc); err != nil {
+		t.Fatalf("Render returned %v", err)
+	}
+	got := out.String()
+
+	// b sits inside the range [1–3], so it links back to the range.
+	for _, want := range []string{
+		`<li id="s1">1. a, 2020 <a class="backref" href="#cite-1-1">↩</a></li>`,
+		`<li id="s2">2. b, 2020 <span class="backrefs">↩ <a href="#cite-1-1">a</a> <a href="#cite-2-1">b</a></span></li>`,
+		`<li id="s3">3. c, 2020 <a class="backref" href="#cite-3-1">↩</a></li>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestBackrefLabel(t *testing.T) {
+	for i, want := range map[int]string{0: "a", 25: "z", 26: "aa", 27: "ab", 701: "zz", 702: "aaa"} {
+		if got := backrefLabel(i); got != want {
+			t.Errorf("Expected label %d to be %q, got %q", i, want, got)
+		}
+	}
+}
+
+func TestHTMLRenderCaseFootnotes(t *testing.T) {
+	bib := ast.NewWebography()
+	for _, keyword := range []string{"a", "b", "c"} {
+		bib.Add(&ast.WBibEntry{Keyword: keyword, Name: keyword, Date: "2020"})
+	}
+
+	doc := &ast.Document{
+		Content: []ast.Node{
+			&ast.CaseNode{Title: "one", Body: []ast.Node{
+				textNode("${a}${b}"),
+			}},
+			&ast.CaseNode{Title: "two", Body: []ast.Node{
+				&ast.CaseNode{Title: "sub", Body: []ast.Node{
+					textNode("${c}${b}"),
+				}},
+				sourcesNode("{footnotes:case}"),
+			}},
+			sourcesNode("{footnotes:all}"),
+		},
+		Webography: bib,
+	}
+
+	var out strings.Builder
+	if err := NewHTML().Render(&out, doc); err != nil {
+		t.Fatalf("Render returned %v", err)
+	}
+	got := out.String()
+
+	for _, want := range []string{
+		// The second case numbers its citations from 1 ...
+		`<p><sup><a id="c2-cite-1-1" href="#c2-s1">[1]</a></sup><sup><a id="c2-cite-2-1" href="#c2-s2">[2]</a></sup></p>`,
+		"<ol>\n" +
+			`<li id="c2-s1">1. c, 2020 <a class="backref" href="#c2-cite-1-1">↩</a></li>` + "\n" +
+			`<li id="c2-s2">2. b, 2020 <a class="backref" href="#c2-cite-2-1">↩</a></li>` + "\n</ol>",
+		// ... while the document list keeps counting across cases.
+		"<ol>\n" +
+			`<li id="s1">1. a, 2020 <a class="backref" href="#cite-1-1">↩</a></li>` + "\n" +
+			`<li id="s2">2. b, 2020 <span class="backrefs">↩ <a href="#cite-2-1">a</a> <a href="#c2-cite-2-1">b</a></span></li>` + "\n" +
+			`<li id="s3">3. c, 2020 <a class="backref" href="#c2-cite-1-1">↩</a></li>` + "\n</ol>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestHTMLRenderParagraphs(t *testing.T) {
+	doc := &ast.Document{
+		NoNLP: true,
+		Content: []ast.Node{
+			&ast.TextNode{Content: "one\ntwo\n\nthree", Paragraphs: parser.ParseParagraphs("one\ntwo\n\nthree"), NLP: true},
+			textNode("{html:<b>raw</b>}\n\nmore"),
+		},
+	}
+
+	r := NewHTML()
+	r.AllowRawHTML = true
+	var out strings.Builder
+	if err := r.Render(&out, doc); err != nil {
+		t.Fatalf("Render returned %v", err)
+	}
+	got := out.String()
+
+	for _, want := range []string{
+		"<p>one<br/>two</p>\n      <p>three</p>\n",
+		"<b>raw</b>\n      more\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestHTMLRenderInlineMarkup(t *testing.T) {
+	doc := &ast.Document{
+		Content: []ast.Node{
+			textNode("{*a} {**b {*c}} {`x {y}} {~d}{_e}{^f}{=g} {https://example.com}{*h} {*}"),
+		},
+	}
+
+	var out strings.Builder
+	if err := NewHTML().Render(&out, doc); err != nil {
+		t.Fatalf("Render returned %v", err)
+	}
+
+	want := "<p><em>a</em> <strong>b <em>c</em></strong> <code>x {y}</code> " +
+		`<del>d</del><sub>e</sub><sup>f</sup><mark>g</mark> <a href="https://example.com">*h</a> {*}</p>`
+	if got := out.String(); !strings.Contains(got, want) {
+		t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+	}
+}
+
+func TestHTMLRenderEscapes(t *testing.T) {
+	bib := ast.NewWebography()
+	bib.Add(&ast.WBibEntry{Keyword: "a", Name: "Tom & <Jerry>", Date: "2020", URL: `https://x.example/?a=1&b="2"`})
+
+	doc := &ast.Document{
+		Title: "<title>",
+		Content: []ast.Node{
+			&ast.CaseNode{Title: `a "case" <x>`, Link: "javascript:alert(1)", Body: []ast.Node{
+				textNode(`1 < 2 & {https://x.example/?q="a"}{<b>} {img/<x>.png}{"alt"} {html:<script>alert(1)</script>} {` + "`" + `<tag>}${a}`),
+				&ast.HTMLNode{Content: "<script>evil()</script>"},
+				sourcesNode("{footnotes}"),
+			}},
+		},
+		Webography: bib,
+	}
+
+	render := func(r *HTML) string {
+		var out strings.Builder
+		if err := r.Render(&out, doc); err != nil {
+			t.Fatalf("Render returned %v", err)
+		}
+		return out.String()
+	}
+
+	got := render(NewHTML())
+	for _, want := range []string{
+		"<title>&lt;title&gt;</title>",
+		`<h4 id="a_&quot;case&quot;_&lt;x&gt;"><a href="#">a "case" &lt;x&gt;</a></h4>`,
+		`<p>1 &lt; 2 &amp; <a href="https://x.example/?q=&quot;a&quot;">&lt;b&gt;</a> <img src="&lt;x&gt;.png" alt="&quot;alt&quot;"/> &lt;script&gt;alert(1)&lt;/script&gt; <code>&lt;tag&gt;</code>`,
+		"      &lt;script&gt;evil()&lt;/script&gt;\n",
+		`1. Tom &amp; &lt;Jerry&gt;, 2020 <a href="https://x.example/?a=1&amp;b=&quot;2&quot;">https://x.example/?a=1&amp;b="2"</a>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+
+	unsafe := NewHTML()
+	unsafe.Unsafe = true
+	got = render(unsafe)
+	for _, want := range []string{
+		`<a href="javascript:alert(1)">a "case" <x></a>`,
+		`<p>1 < 2 & <a href="https://x.example/?q="a""><b></a>`,
+		"<script>alert(1)</script> <code>&lt;tag&gt;</code>",
+		"      <script>evil()</script>\n",
+		"1. Tom & <Jerry>, 2020",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected unsafe output to contain %q, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(render(NewHTML()), "<script>") {
+		t.Errorf("Expected safe output to escape every <script>")
+	}
+
+	raw := NewHTML()
+	raw.AllowRawHTML = true
+	got = render(raw)
+	for _, want := range []string{
+		`<p>1 &lt; 2 &amp; <a href="https://x.example/?q=&quot;a&quot;">&lt;b&gt;</a>`,
+		"<script>alert(1)</script> <code>&lt;tag&gt;</code>",
+		"      <script>evil()</script>\n",
+		`<a href="#">a "case" &lt;x&gt;</a>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output with raw HTML to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestHTMLCheckRawHTML(t *testing.T) {
+	text := textNode("a {html:<b>} b")
+	text.From = ast.Position{Line: 2, Column: 3}
+	doc := &ast.Document{
+		Content: []ast.Node{
+			text,
+			&ast.CaseNode{Title: "c", Body: []ast.Node{
+				&ast.HTMLNode{Span: ast.Span{From: ast.Position{Line: 5, Column: 5}}, Content: "<hr/>"},
+			}},
+		},
+	}
+
+	var got []string
+	for _, d := range NewHTML().Check(doc) {
+		got = append(got, d.Error())
+	}
+	want := []string{
+		"2:3: warning: {html:...} is escaped; render with -raw-html to keep it [raw-html]",
+		"5:5: warning: html block is escaped; render with -raw-html to keep it [raw-html]",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected diagnostics:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	unsafe := NewHTML()
+	unsafe.Unsafe = true
+	raw := NewHTML()
+	raw.AllowRawHTML = true
+	for _, r := range []*HTML{unsafe, raw} {
+		if diags := r.Check(doc); len(diags) != 0 {
+			t.Errorf("Expected no diagnostics when raw HTML is allowed, got %v", diags)
+		}
+	}
+}
+
+func TestHTMLRenderCode(t *testing.T) {
+	doc := &ast.Document{
+		Content: []ast.Node{
+			&ast.CodeNode{Lang: "go", Content: "if a < b {\n\n\treturn \"x\"\n}"},
+			&ast.CodeNode{Content: "<raw>"},
+		},
+	}
+
+	var out strings.Builder
+	if err := NewHTML().Render(&out, doc); err != nil {
+		t.Fatalf("Render returned %v", err)
+	}
+	got := out.String()
+
+	for _, want := range []string{
+		`<pre><code class="language-go"><span class="hl-keyword">if</span> a &lt; b {` + "\n\n\t" +
+			`<span class="hl-keyword">return</span> <span class="hl-string">"x"</span>` + "\n}</code></pre>",
+		"<pre><code>&lt;raw&gt;</code></pre>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestHTMLRenderList(t *testing.T) {
+	doc := &ast.Document{
+		Content: []ast.Node{
+			&ast.ListNode{Items: []*ast.ListItem{
+				{Content: parser.ParseInline("a {*b}")},
+				{Content: parser.ParseInline("c"), Sublists: []*ast.ListNode{
+					{Ordered: true, Items: []*ast.ListItem{{Content: parser.ParseInline("d")}}},
+				}},
+			}},
+		},
+	}
+
+	var out strings.Builder
+	if err := NewHTML().Render(&out, doc); err != nil {
+		t.Fatalf("Render returned %v", err)
+	}
+
+	want := `    <ul>
+      <li>a <em>b</em></li>
+      <li>c
+        <ol>
+          <li>d</li>
+        </ol>
+      </li>
+    </ul>
+`
+	if got := out.String(); !strings.Contains(got, want) {
+		t.Errorf("Expected output to contain:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestHTMLRenderTable(t *testing.T) {
+	cells := func(texts ...string) []ast.TableCell {
+		var row []ast.TableCell
+		for _, text := range texts {
+			row = append(row, ast.TableCell{Text: text, Content: parser.ParseInline(text)})
+		}
+		return row
+	}
+	doc := &ast.Document{
+		Content: []ast.Node{
+			&ast.TableNode{
+				Caption: parser.ParseInline("Some {*data}"),
+				Align:   []ast.Alignment{ast.AlignDefault, ast.AlignRight},
+				Header:  cells("a", "b"),
+				Rows:    [][]ast.TableCell{cells("1 < 2", "3"), cells("4")},
+			},
+		},
+	}
+
+	var out strings.Builder
+	if err := NewHTML().Render(&out, doc); err != nil {
+		t.Fatalf("Render returned %v", err)
+	}
+
+	want := `    <table>
+      <caption>Some <em>data</em></caption>
+      <thead>
+        <tr><th>a</th><th style="text-align: right">b</th></tr>
+      </thead>
+      <tbody>
+        <tr><td>1 &lt; 2</td><td style="text-align: right">3</td></tr>
+        <tr><td>4</td><td style="text-align: right"></td></tr>
+      </tbody>
+    </table>
+`
+	if got := out.String(); !strings.Contains(got, want) {
+		t.Errorf("Expected output to contain:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestHTMLRenderQuoteAndCallout(t *testing.T) {
+	bib := ast.NewWebography()
+	bib.Add(&ast.WBibEntry{Keyword: "knuth", Name: "Structured Programming", Authors: []string{"Knuth, Donald"}})
+	quote := &ast.QuoteNode{Content: "Root of <all> evil."}
+	quote.Paragraphs = parser.ParseParagraphs(quote.Content)
+	quote.Attribution = parser.ParseInline("${knuth}")
+	note := &ast.CalloutNode{Type: ast.CalloutNote, Title: parser.ParseInline("{*Heads} up"), Content: "One\n\nTwo"}
+	note.Paragraphs = parser.ParseParagraphs(note.Content)
+
+	doc := &ast.Document{NoNLP: true, Webography: bib, Content: []ast.Node{quote, note}}
+
+	var out strings.Builder
+	if err := NewHTML().Render(&out, doc); err != nil {
+		t.Fatalf("Render returned %v", err)
+	}
+
+	want := `    <blockquote>
+      <p>Root of &lt;all&gt; evil.</p>
+      <footer>— <sup><a id="cite-1-1" href="#s1">[1]</a></sup></footer>
+    </blockquote>
+    <aside class="note">
+      <p class="callout-title"><em>Heads</em> up</p>
+      <p>One</p>
+      <p>Two</p>
+    </aside>
+`
+	if got := out.String(); !strings.Contains(got, want) {
+		t.Errorf("Expected output to contain:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestHTMLRenderFigure(t *testing.T) {
+	figure := &ast.FigureNode{
+		ID:      "arch",
+		Number:  1,
+		Caption: parser.ParseInline("The {*overall} design"),
+		Content: parser.ParseInline("{img/arch.png}{Boxes}"),
+		Body:    []ast.Node{&ast.CodeNode{Content: "x"}},
+	}
+	doc := &ast.Document{
+		Content: []ast.Node{
+			textNode("See {ref:arch}, not {ref:nope}."),
+			figure,
+			&ast.FigureNode{Number: 2},
+		},
+	}
+
+	var out strings.Builder
+	if err := NewHTML().Render(&out, doc); err != nil {
+		t.Fatalf("Render returned %v", err)
+	}
+	got := out.String()
+
+	for _, want := range []string{
+		`<p>See <a class="figure-ref" href="#figure-arch">Figure 1</a>, not ??.</p>`,
+		`    <figure id="figure-arch">
+      <img src="arch.png" alt="Boxes"/>
+      <pre><code>x</code></pre>
+      <figcaption>Figure 1: The <em>overall</em> design</figcaption>
+    </figure>
+    <figure id="figure-2">
+      <figcaption>Figure 2</figcaption>
+    </figure>
+`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected output to contain:\n%s\ngot:\n%s", want, got)
+		}
+	}
+}
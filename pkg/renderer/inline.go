@@ -0,0 +1,202 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package renderer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+	"github.com/nanomarkdown/nanami/pkg/cite"
+)
+
+// styleTags maps inline styles to the HTML elements that render them.
+var styleTags = map[ast.InlineStyle]string{
+	ast.Emphasis:    "em",
+	ast.Strong:      "strong",
+	ast.Strike:      "del",
+	ast.Subscript:   "sub",
+	ast.Superscript: "sup",
+	ast.Highlight:   "mark",
+}
+
+// inlines renders parsed inline content.
+func (h *htmlWriter) inlines(nodes []ast.Inline) string {
+	var result strings.Builder
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *ast.Text:
+			result.WriteString(h.escape(n.Value))
+		case *ast.RawHTML:
+			result.WriteString(h.raw(n.Value))
+		case *ast.LineBreak:
+			result.WriteString("<br/>")
+		case *ast.Styled:
+			tag := styleTags[n.Style]
+			result.WriteString("<" + tag + ">" + h.inlines(n.Children) + "</" + tag + ">")
+		case *ast.Code:
+			// Code is verbatim, so it is escaped even in unsafe mode.
+			result.WriteString("<code>" + textEscaper.Replace(n.Value) + "</code>")
+		case *ast.Link:
+			text := h.escape(n.URL) // Default link text is the URL
+			if len(n.Children) > 0 {
+				text = h.inlines(n.Children)
+			}
+			fmt.Fprintf(&result, `<a href="%s">%s</a>`, h.url(n.URL), text)
+		case *ast.Image:
+			fmt.Fprintf(&result, `<img src="%s" alt="%s"/>`, h.url(n.Src), h.attr(n.Alt))
+		case *ast.Citation:
+			result.WriteString(h.citation(*n))
+		case *ast.Footnotes:
+			result.WriteString(h.footnotes(n.Scope))
+		case *ast.Ref:
+			result.WriteString(h.ref(n.ID))
+		}
+	}
+	return result.String()
+}
+
+// citation renders an in-text citation. Keywords missing from the
+// webography are left out. Every link gets an anchor the bibliography can
+// link back to; entries hidden inside a range such as [2–4] share the
+// anchor of the citation's first link.
+func (h *htmlWriter) citation(citation ast.Citation) string {
+	var refs []cite.Ref
+	for _, item := range citation.Items {
+		if n, ok := h.cites.cite(item.Keyword); ok {
+			refs = append(refs, cite.Ref{Entry: h.cites.entry(n), N: n, Locator: item.Locator})
+		}
+	}
+	if len(refs) == 0 {
+		return ""
+	}
+
+	// Narrative citations keep the author's name on the line and raise
+	// only the markers after it.
+	narrative := citation.Mode == ast.CiteNarrative
+	var first string
+	linked := map[int]bool{}
+	marker := h.style.Cite(refs, citation.Mode, func(n int, text string) string {
+		id := h.cites.site(n)
+		if first == "" {
+			first = id
+		}
+		linked[n] = true
+
+		link := fmt.Sprintf(`<a id="%s" href="#%s">%s</a>`, id, h.cites.anchor(n), text)
+		if h.style.Superscript && narrative {
+			link = "<sup>" + link + "</sup>"
+		}
+		return link
+	}, h.field)
+	for _, ref := range refs {
+		if !linked[ref.N] {
+			linked[ref.N] = true
+			h.cites.citedAt(ref.N, first)
+		}
+	}
+
+	if h.style.Superscript && !narrative {
+		marker = "<sup>" + marker + "</sup>"
+	}
+	return marker
+}
+
+// ref renders a reference to a figure. References to unknown figures
+// render as ??, as in LaTeX; check reports them.
+func (h *htmlWriter) ref(id string) string {
+	figure, ok := h.figures[id]
+	if !ok {
+		return "??"
+	}
+	return fmt.Sprintf(`<a class="figure-ref" href="#%s">Figure %d</a>`,
+		h.attr(figureAnchor(figure)), figure.Number)
+}
+
+// field escapes the fields of citations and turns URLs into links.
+func (h *htmlWriter) field(field, value string) string {
+	if field == "url" || field == "archive" {
+		return fmt.Sprintf(`<a href="%s">%s</a>`, h.url(value), h.escape(value))
+	}
+	return h.escape(value)
+}
+
+// footnotes lists the entries cited in scope: the current numbering, the
+// enclosing top-level case, which then has a numbering of its own, or the
+// whole document.
+func (h *htmlWriter) footnotes(which ast.FootnotesScope) string {
+	scope := h.cites.current
+	if which == ast.FootnotesAll {
+		scope = h.cites.document
+	}
+	if len(scope.ordered) == 0 {
+		return ""
+	}
+
+	entries := append([]*ast.WBibEntry(nil), scope.ordered...)
+	h.style.Sort(entries)
+
+	// Author-date bibliographies are not numbered.
+	list := "ol"
+	if h.style.Order == cite.OrderAuthor {
+		list = "ul"
+	}
+
+	var result strings.Builder
+	result.WriteString("<" + list + ">\n")
+
+	for _, entry := range entries {
+		n := scope.numbers[entry.Keyword]
+		result.WriteString(fmt.Sprintf(`<li id="%s">%s%s</li>`,
+			scope.anchor(n), h.style.Entry(entry, n, h.field), backrefs(scope.sites[n])))
+		result.WriteString("\n")
+	}
+
+	result.WriteString("</" + list + ">")
+	return result.String()
+}
+
+// backrefs links a bibliography entry back to the places it was cited: a
+// single ↩ for one citation, or ↩ a b c for several.
+func backrefs(ids []string) string {
+	switch len(ids) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf(` <a class="backref" href="#%s">↩</a>`, ids[0])
+	}
+
+	var result strings.Builder
+	result.WriteString(` <span class="backrefs">↩`)
+	for i, id := range ids {
+		fmt.Fprintf(&result, ` <a href="#%s">%s</a>`, id, backrefLabel(i))
+	}
+	result.WriteString("</span>")
+	return result.String()
+}
+
+// backrefLabel names the i-th back-link: a to z, then aa, ab and so on.
+func backrefLabel(i int) string {
+	var label string
+	for i++; i > 0; i /= 26 {
+		i--
+		label = string(rune('a'+i%26)) + label
+	}
+	return label
+}
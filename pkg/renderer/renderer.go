@@ -0,0 +1,31 @@
+/*
+Copyright 2025 rivst.
+This file is part of nanami.
+
+nanami is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+nanami is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with nanami. If not, see <https://www.gnu.org/licenses/>.
+*/
+
+package renderer
+
+import (
+	"io"
+
+	"github.com/nanomarkdown/nanami/pkg/ast"
+)
+
+// Renderer turns a parsed document into some output format. Implementations
+// walk the AST themselves and must not keep state between calls to Render.
+type Renderer interface {
+	Render(w io.Writer, doc *ast.Document) error
+}